@@ -25,7 +25,8 @@ type calls struct {
 	p        *pageOffset
 	mem      *phyMemory
 	services map[uint32]vpc.Service
-	enabled  map[uint32]bool
+	names    map[uint32]string
+	enabled  map[uint32]bool // services that can send messages
 	queue    *list.List
 
 	timedSleep bool
@@ -37,63 +38,27 @@ func newCalls(p *page, mem *phyMemory) *calls {
 		p:        &pageOffset{p, 0},
 		mem:      mem,
 		services: make(map[uint32]vpc.Service),
+		names:    make(map[uint32]string),
+		enabled:  make(map[uint32]bool),
 		queue:    list.New(),
 	}
 }
 
 func (c *calls) sender(id uint32) vpc.Sender {
-	return &callsSender{service: id, queue: c.queue}
+	return &callsSender{service: id, calls: c}
 }
 
-func (c *calls) register(id uint32, s vpc.Service) {
+// register registers a service with a name. A newly registered service
+// can send messages by default.
+func (c *calls) register(id uint32, name string, s vpc.Service) {
 	if id == 0 {
 		panic("cannot register service 0")
 	}
-	c.services[id] = s
-}
-
-func (c *calls) system(ctrl uint8, in []byte, respSize int) (
-	[]byte, int32, *Excep,
-) {
-	switch ctrl {
-	case 1: // poll message
-		if c.queue.Len() == 0 {
-			if len(in) == 0 {
-				c.timedSleep = false
-				return nil, vpc.ErrInternal, errSleep // we will execute again
-			}
-			if len(in) != 8 {
-				return nil, vpc.ErrInvalidArg, nil
-			}
-
-			// first time executing sleep.
-			if !c.timedSleep {
-				c.timedSleep = true
-				c.sleep = time.Duration(Endian.Uint64(in[:8]))
-				return nil, vpc.ErrInternal, errSleep
-			}
-
-			// second time, timeout, waking up.
-			c.timedSleep = false
-			return nil, vpc.ErrTimeout, nil
-		}
-
-		front := c.queue.Front()
-		m := front.Value.(*callsMessage)
-		if len(m.p) > respSize {
-			return nil, vpc.ErrSmallBuf, nil
-		}
-
-		c.queue.Remove(front)
-		c.p.writeWord(callsService, m.service) // overwrite the service
-		return m.p, 0, nil
-
-	// TODO(h8liu): add other stuff
-	case 2: // list services
-	case 3: // enable/disable service message
+	if _, found := c.services[id]; !found {
+		c.enabled[id] = true
 	}
-
-	return nil, vpc.ErrInvalidArg, nil
+	c.services[id] = s
+	c.names[id] = name
 }
 
 func (c *calls) call(ctrl uint8, s uint32, req []byte, respSize int) (
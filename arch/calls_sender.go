@@ -1,9 +1,5 @@
 package arch
 
-import (
-	"container/list"
-)
-
 type callsMessage struct {
 	service uint32
 	p       []byte
@@ -11,13 +7,17 @@ type callsMessage struct {
 
 type callsSender struct {
 	service uint32
-	queue   *list.List
+	calls   *calls
 }
 
+// Send queues a message, unless the service is muted by the guest.
 func (s *callsSender) Send(bs []byte) {
+	if !s.calls.enabled[s.service] {
+		return
+	}
 	m := &callsMessage{
 		service: s.service,
 		p:       bs,
 	}
-	s.queue.PushBack(m)
+	s.calls.queue.PushBack(m)
 }
@@ -0,0 +1,129 @@
+package arch
+
+import (
+	"bytes"
+	"container/list"
+	"sort"
+	"time"
+
+	"shanhu.io/smlvm/arch/vpc"
+	"shanhu.io/smlvm/coder"
+)
+
+// Controls of the system service, which is service 0.
+const (
+	callsPoll   = 1 // polls the next message
+	callsList   = 2 // lists the registered services
+	callsEnable = 3 // enables or disables messages from a service
+)
+
+type uint32s []uint32
+
+func (s uint32s) Len() int           { return len(s) }
+func (s uint32s) Less(i, j int) bool { return s[i] < s[j] }
+func (s uint32s) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func (c *calls) poll(in []byte, respSize int) ([]byte, int32, *Excep) {
+	if c.queue.Len() == 0 {
+		if len(in) == 0 {
+			c.timedSleep = false
+			return nil, vpc.ErrInternal, errSleep // we will execute again
+		}
+		if len(in) != 8 {
+			return nil, vpc.ErrInvalidArg, nil
+		}
+
+		// first time executing sleep.
+		if !c.timedSleep {
+			c.timedSleep = true
+			c.sleep = time.Duration(Endian.Uint64(in[:8]))
+			return nil, vpc.ErrInternal, errSleep
+		}
+
+		// second time, timeout, waking up.
+		c.timedSleep = false
+		return nil, vpc.ErrTimeout, nil
+	}
+
+	front := c.queue.Front()
+	m := front.Value.(*callsMessage)
+	if len(m.p) > respSize {
+		return nil, vpc.ErrSmallBuf, nil
+	}
+
+	c.queue.Remove(front)
+	c.p.writeWord(callsService, m.service) // overwrite the service
+	return m.p, 0, nil
+}
+
+// list returns the registered services ordered by the service IDs. Each
+// service is encoded as the uint32 ID, followed by a byte of the name
+// length and the name.
+func (c *calls) list(in []byte) ([]byte, int32) {
+	if len(in) != 0 {
+		return nil, vpc.ErrInvalidArg
+	}
+
+	var ids []uint32
+	for id := range c.services {
+		ids = append(ids, id)
+	}
+	sort.Sort(uint32s(ids))
+
+	buf := new(bytes.Buffer)
+	for _, id := range ids {
+		name := c.names[id]
+		if len(name) > 255 {
+			name = name[:255]
+		}
+		var bs [4]byte
+		Endian.PutUint32(bs[:], id)
+		buf.Write(bs[:])
+		buf.WriteByte(byte(len(name)))
+		buf.WriteString(name)
+	}
+	return buf.Bytes(), 0
+}
+
+// enable takes a uint32 service ID and a byte, and mutes the messages
+// from the service if the byte is 0, or unmutes them otherwise. Messages
+// from a muted service are dropped, including the ones in the queue.
+func (c *calls) enable(in []byte) int32 {
+	dec := coder.NewDecoder(in)
+	id := dec.U32()
+	on := dec.U8()
+	if dec.Err != nil || len(in) != 5 {
+		return vpc.ErrInvalidArg
+	}
+	if _, found := c.services[id]; !found {
+		return vpc.ErrNotFound
+	}
+
+	c.enabled[id] = on != 0
+	if on == 0 {
+		var next *list.Element
+		for e := c.queue.Front(); e != nil; e = next {
+			next = e.Next()
+			if e.Value.(*callsMessage).service == id {
+				c.queue.Remove(e)
+			}
+		}
+	}
+	return 0
+}
+
+func (c *calls) system(ctrl uint8, in []byte, respSize int) (
+	[]byte, int32, *Excep,
+) {
+	switch ctrl {
+	case callsPoll:
+		return c.poll(in, respSize)
+	case callsList:
+		ret, code := c.list(in)
+		return ret, code, nil
+	case callsEnable:
+		return nil, c.enable(in), nil
+	}
+
+	return nil, vpc.ErrInvalidArg, nil
+}
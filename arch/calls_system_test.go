@@ -0,0 +1,58 @@
+package arch
+
+import (
+	"bytes"
+	"testing"
+
+	"shanhu.io/smlvm/arch/vpc"
+)
+
+func TestCallsList(t *testing.T) {
+	m := NewMachine(new(Config))
+	resp, code, e := m.calls.system(callsList, nil, vpc.MaxLen)
+	if e != nil || code != 0 {
+		t.Fatalf("list failed: code=%d, e=%v", code, e)
+	}
+	want := []byte{
+		1, 0, 0, 0, 7, 'c', 'o', 'n', 's', 'o', 'l', 'e',
+		3, 0, 0, 0, 4, 'r', 'a', 'n', 'd',
+		4, 0, 0, 0, 5, 'c', 'l', 'o', 'c', 'k',
+		7, 0, 0, 0, 5, 't', 'i', 'm', 'e', 'r',
+	}
+	if !bytes.Equal(resp, want) {
+		t.Errorf("got %q, want %q", resp, want)
+	}
+}
+
+func TestCallsEnable(t *testing.T) {
+	m := NewMachine(new(Config))
+	c := m.calls
+	s := c.sender(serviceConsole)
+	s.Send([]byte{1})
+
+	if code := c.enable([]byte{1, 0, 0, 0, 0}); code != 0 {
+		t.Fatalf("disable got code %d", code)
+	}
+	if n := c.queueLen(); n != 0 {
+		t.Errorf("%d messages left in the queue after muting", n)
+	}
+	s.Send([]byte{2})
+	if n := c.queueLen(); n != 0 {
+		t.Errorf("muted service sent %d messages", n)
+	}
+
+	if code := c.enable([]byte{1, 0, 0, 0, 1}); code != 0 {
+		t.Fatalf("enable got code %d", code)
+	}
+	s.Send([]byte{3})
+	if n := c.queueLen(); n != 1 {
+		t.Errorf("got %d messages, want 1", n)
+	}
+
+	if code := c.enable([]byte{9, 0, 0, 0, 0}); code != vpc.ErrNotFound {
+		t.Errorf("disabling unknown service got code %d", code)
+	}
+	if code := c.enable([]byte{1, 0}); code != vpc.ErrInvalidArg {
+		t.Errorf("short request got code %d", code)
+	}
+}
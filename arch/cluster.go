@@ -0,0 +1,128 @@
+package arch
+
+import (
+	"fmt"
+	"time"
+
+	"shanhu.io/smlvm/arch/link"
+)
+
+// ClusterConfig contains the configuration of a cluster.
+type ClusterConfig struct {
+	Latency  int     // ticks that a packet takes to arrive
+	DropRate float64 // probability that a packet is dropped
+	Seed     int64   // random seed for dropping packets
+
+	// TickTime is the virtual time of a tick, used for timing out the
+	// message polls. DefaultTickTime is used if it is 0.
+	TickTime time.Duration
+}
+
+type clusterNode struct {
+	m        *Machine
+	sleeping bool
+	wake     int64 // the tick to wake up at, -1 for waiting a message
+}
+
+// Cluster is a set of machines connected by a virtual link. The
+// machines are ticked in turn in the same goroutine, so a cluster
+// always runs the same way given the same inputs and seeds.
+type Cluster struct {
+	nodes    []*clusterNode
+	net      *link.Network
+	tickTime time.Duration
+	now      int64
+}
+
+// NewCluster creates an empty cluster.
+func NewCluster(c *ClusterConfig) *Cluster {
+	tickTime := c.TickTime
+	if tickTime <= 0 {
+		tickTime = DefaultTickTime
+	}
+	return &Cluster{
+		net: link.NewNetwork(&link.Config{
+			Latency:  c.Latency,
+			DropRate: c.DropRate,
+			Seed:     c.Seed,
+		}),
+		tickTime: tickTime,
+	}
+}
+
+// Add adds a machine into the cluster, and registers the link service
+// on it. It returns the link address of the machine. The machine
+// should not run in parallel mode.
+func (c *Cluster) Add(m *Machine) uint32 {
+	e := c.net.Endpoint(m.calls.sender(serviceLink))
+	m.calls.register(serviceLink, "link", e)
+	c.nodes = append(c.nodes, &clusterNode{m: m})
+	return e.Addr()
+}
+
+// Machine returns the machine of a link address.
+func (c *Cluster) Machine(addr uint32) *Machine {
+	return c.nodes[addr].m
+}
+
+// ClusterExcep is an exception on a machine in a cluster.
+type ClusterExcep struct {
+	Addr uint32 // link address of the machine
+	*CoreExcep
+}
+
+func (e *ClusterExcep) Error() string {
+	return fmt.Sprintf("machine %d: %s", e.Addr, e.CoreExcep.Excep)
+}
+
+func (c *Cluster) tickNode(n *clusterNode) *CoreExcep {
+	m := n.m
+	if n.sleeping {
+		if !m.HasPending() && (n.wake < 0 || c.now < n.wake) {
+			m.tickDevices()
+			return nil
+		}
+		n.sleeping = false
+	}
+
+	e := m.Tick()
+	if e == nil || e.Code != ErrSleep {
+		return e
+	}
+	if d, timed := m.SleepTime(); timed {
+		n.sleeping = true
+		n.wake = c.now + int64(d/c.tickTime)
+	} else if !m.HasPending() {
+		n.sleeping = true
+		n.wake = -1
+	}
+	return nil
+}
+
+// Tick ticks all the machines once, in the order that they are added,
+// and then the link.
+func (c *Cluster) Tick() *ClusterExcep {
+	for i, n := range c.nodes {
+		if e := c.tickNode(n); e != nil {
+			return &ClusterExcep{uint32(i), e}
+		}
+	}
+	c.net.Tick()
+	c.now++
+	return nil
+}
+
+// Run runs the cluster for nticks, or forever if nticks is 0. It stops
+// at the first exception of any machine, other than the sleeps waiting
+// for messages. It returns the number of ticks run and the exception.
+func (c *Cluster) Run(nticks int) (int, *ClusterExcep) {
+	n := 0
+	for nticks == 0 || n < nticks {
+		e := c.Tick()
+		n++
+		if e != nil {
+			return n, e
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,102 @@
+package arch
+
+import (
+	"bytes"
+	"testing"
+
+	"shanhu.io/smlvm/arch/vpc"
+	asminst "shanhu.io/smlvm/asm/inst"
+)
+
+const (
+	testReqAddr  = 0x10000
+	testRespAddr = 0x11000
+)
+
+// newCallMachine creates a machine that makes an IOCALL with the given
+// service and request, and then either halts or spins.
+func newCallMachine(
+	t *testing.T, service uint32, req []byte, spin bool,
+) *Machine {
+	last := asminst.Sys(HALT, 0, 0)
+	if spin {
+		last = asminst.Jmp(J, -1)
+	}
+	m := newTestMachine(t, 1, []uint32{
+		asminst.Sys(IOCALL, 0, 0),
+		last,
+	})
+	for i, b := range req {
+		m.phyMem.WriteByte(testReqAddr+uint32(i), b)
+	}
+	p := m.calls.p
+	p.writeByte(callsControl, 1)
+	p.writeWord(callsService, service)
+	p.writeWord(callsRequestAddr, testReqAddr)
+	p.writeWord(callsRequestLen, uint32(len(req)))
+	p.writeWord(callsResponseAddr, testRespAddr)
+	p.writeWord(callsResponseSize, 100)
+	return m
+}
+
+func TestCluster(t *testing.T) {
+	const latency = 20
+	c := NewCluster(&ClusterConfig{Latency: latency})
+	msg := []byte{1, 1, 0, 0, 0, 'h', 'i'} // send "hi" to 1
+	a := newCallMachine(t, serviceLink, msg, true)
+	b := newCallMachine(t, 0, nil, false) // poll
+	if addr := c.Add(a); addr != 0 {
+		t.Fatalf("got address %d, want 0", addr)
+	}
+	if addr := c.Add(b); addr != 1 {
+		t.Fatalf("got address %d, want 1", addr)
+	}
+
+	n, e := c.Run(1000)
+	if e == nil || e.Addr != 1 || !IsHalt(e.CoreExcep.Excep) {
+		t.Fatalf("want machine 1 halting, got %v", e)
+	}
+	if n < latency {
+		t.Errorf("packet arrived after %d ticks, faster than latency", n)
+	}
+
+	p := b.calls.p
+	if s := p.readWord(callsService); s != serviceLink {
+		t.Errorf("got message from service %d", s)
+	}
+	if code := p.readWord(callsResponseCode); code != 0 {
+		t.Errorf("poll got error %d", code)
+	}
+	got := make([]byte, p.readWord(callsResponseLen))
+	for i := range got {
+		got[i], _ = b.phyMem.ReadByte(testRespAddr + uint32(i))
+	}
+	if want := []byte{0, 0, 0, 0, 'h', 'i'}; !bytes.Equal(got, want) {
+		t.Errorf("got packet %v, want %v", got, want)
+	}
+}
+
+func TestClusterDrop(t *testing.T) {
+	const timeout = 50
+	c := NewCluster(&ClusterConfig{DropRate: 1})
+	msg := []byte{1, 1, 0, 0, 0, 'h', 'i'}
+	c.Add(newCallMachine(t, serviceLink, msg, true))
+
+	// polls with a timeout
+	req := make([]byte, 8)
+	Endian.PutUint64(req, uint64(timeout*DefaultTickTime))
+	b := newCallMachine(t, 0, req, false)
+	c.Add(b)
+
+	n, e := c.Run(1000)
+	if e == nil || e.Addr != 1 || !IsHalt(e.CoreExcep.Excep) {
+		t.Fatalf("want machine 1 halting, got %v", e)
+	}
+	if n < timeout || n > timeout+5 {
+		t.Errorf("poll timed out after %d ticks, want %d", n, timeout)
+	}
+	code := int32(b.calls.p.readWord(callsResponseCode))
+	if code != vpc.ErrTimeout {
+		t.Errorf("got code %d, want timeout", code)
+	}
+}
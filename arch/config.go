@@ -4,18 +4,24 @@ import (
 	"io"
 	"time"
 
+	"shanhu.io/smlvm/arch/display"
 	"shanhu.io/smlvm/arch/screen"
 	"shanhu.io/smlvm/arch/table"
 )
 
+// DefaultTickTime is the default virtual time of a tick.
+const DefaultTickTime = time.Microsecond
+
 // Config contains config for constructing a machine
 type Config struct {
 	MemSize uint32
 	Ncore   int
 
 	Output   io.Writer
+	Input    io.Reader // console input, read on a separate goroutine
 	Screen   screen.Render
 	Table    table.Render
+	Display  display.Render // pixel display, not attached if nil
 	RandSeed int64
 
 	InitPC       uint32
@@ -24,7 +30,46 @@ type Config struct {
 
 	BootArg uint32
 
-	ROM string
+	// LegacyDivZero makes integer division and modulo by zero return 0,
+	// as older images expect, rather than raising ErrDivZero.
+	LegacyDivZero bool
+
+	ROM  string
+	Disk string // path of the disk image of the block device
 
 	PerfNow func() time.Duration
+
+	// Record writes a log of the nondeterministic inputs into a writer,
+	// which can be read with ReadInputLog and replayed with Replay.
+	// Replay runs the machine with the inputs in the log, so that it
+	// runs the same way as recorded. Host inputs, such as the console
+	// input and the clicks, are ignored when replaying. The disk image
+	// is neither read nor written when replaying.
+	Record io.Writer
+	Replay *InputLog
+
+	// Session records the screen updates, the table actions and the
+	// clicks into a writer, which can be played with session.Player.
+	// The events are stamped with the virtual time of the machine, and
+	// the screen updates are recorded on every tick that has them, so
+	// the same run records the same session with any Screen. The screen
+	// and the table are attached when recording a session, even if
+	// Screen or Table is nil.
+	Session io.Writer
+
+	// TickTime is the virtual time of a tick, used by the timer service
+	// for durations in nanoseconds. DefaultTickTime is used if it is 0.
+	TickTime time.Duration
+
+	Tracer   Tracer    // receives the trace of every tick if not nil
+	Profiler *Profiler // samples the call stacks if not nil
+
+	// Parallel runs the cores on separate goroutines in Run, in quanta
+	// of Quantum ticks; DefaultQuantum is used if Quantum is 0. It is
+	// faster on multicore machines, but not deterministic, so it is off
+	// by default. Run falls back to the sequential mode when tracing,
+	// profiling, recording for reverse execution, or recording or
+	// replaying the inputs.
+	Parallel bool
+	Quantum  int
 }
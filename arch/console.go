@@ -19,6 +19,9 @@ type console struct {
 	IntOut byte
 
 	Output io.Writer
+
+	input  <-chan byte // bytes read from the input, nil if no input
+	inputs *inputLog
 }
 
 // NewConsole creates a new simple console.
@@ -41,8 +44,88 @@ const (
 
 	consoleIn      = 4
 	consoleInValid = 5
+
+	consoleInEOF = 2 // consoleInValid at the end of the input
 )
 
+// setInput starts reading the input on a goroutine. The bytes are fed
+// into the console one at a time when the program consumes the last
+// one by clearing consoleInValid.
+func (c *console) setInput(r io.Reader) {
+	if c.inputs.replaying() {
+		return
+	}
+	ch := make(chan byte, 4096)
+	c.input = ch
+	go readInput(r, ch)
+}
+
+func readInput(r io.Reader, ch chan<- byte) {
+	buf := make([]byte, 256)
+	for {
+		n, err := r.Read(buf)
+		for _, b := range buf[:n] {
+			ch <- b
+		}
+		if err != nil {
+			if err != io.EOF {
+				log.Print(err)
+			}
+			close(ch)
+			return
+		}
+	}
+}
+
+// nextInput returns the next input byte if any, where eof is true at
+// the end of the input.
+func (c *console) nextInput() (b byte, eof, ok bool) {
+	if c.inputs.replaying() {
+		bs, ok := c.inputs.next(inputConsole)
+		if !ok {
+			return 0, false, false
+		}
+		if len(bs) == 0 {
+			return 0, true, true
+		}
+		return bs[0], false, true
+	}
+
+	if c.input == nil {
+		return 0, false, false
+	}
+	select {
+	case b, ok := <-c.input:
+		if !ok {
+			c.input = nil
+			c.inputs.record(inputConsole, nil)
+			return 0, true, true
+		}
+		c.inputs.record(inputConsole, []byte{b})
+		return b, false, true
+	default:
+		return 0, false, false
+	}
+}
+
+func (c *console) tickInput() {
+	if c.p.readByte(consoleInValid) != 0 {
+		return
+	}
+
+	b, eof, ok := c.nextInput()
+	if !ok {
+		return
+	}
+	if eof {
+		c.p.writeByte(consoleInValid, consoleInEOF)
+	} else {
+		c.p.writeByte(consoleIn, b)
+		c.p.writeByte(consoleInValid, 1)
+	}
+	c.interrupt(c.IntIn) // in available
+}
+
 func (c *console) interrupt(code byte) {
 	c.intBus.Interrupt(code, c.Core)
 }
@@ -64,7 +147,8 @@ func (c *console) Handle(req []byte) ([]byte, int32) {
 	return nil, 0
 }
 
-// Tick flushes the buffered byte to the console.
+// Tick flushes the buffered byte to the console, and feeds in the next
+// input byte if any.
 func (c *console) Tick() {
 	outValid := c.p.readByte(consoleOutValid)
 	if outValid != 0 {
@@ -77,5 +161,5 @@ func (c *console) Tick() {
 		c.interrupt(c.IntOut) // out available
 	}
 
-	// TODO(h8liu): input part
+	c.tickInput()
 }
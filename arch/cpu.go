@@ -9,6 +9,11 @@ type inst interface {
 	I(cpu *cpu, in uint32) *Excep
 }
 
+// memWatcher watches the memory accesses of instructions.
+type memWatcher interface {
+	watch(core byte, addr, n uint32, write bool)
+}
+
 // CPU defines the structure of a processing unit.
 type cpu struct {
 	regs []uint32
@@ -20,9 +25,19 @@ type cpu struct {
 	calls     *calls
 
 	inst     inst
+	arch8    *instArch8 // inst if it is arch8, for predecoding
+	icache   instCache
 	index    byte
 	ncycle   uint64
 	sleeping bool
+	halted   bool       // a halted core does not tick
+	cores    *multiCore // the processor that the core belongs to
+
+	watcher memWatcher
+	yieldIO bool // yields on IOCALL when running in parallel
+
+	tracer     Tracer
+	intEntered int // the interrupt code entered, -1 for none
 }
 
 // newCPU creates a CPU with memroy and instruction binding
@@ -44,6 +59,9 @@ func newCPU(mem *phyMemory, calls *calls, i inst, index byte) *cpu {
 	}
 	ret.interrupt = newInterrupt(intPage, index)
 	ret.inst = i
+	if a, ok := i.(*instArch8); ok {
+		ret.arch8 = a
+	}
 
 	ret.regs[PC] = InitPC
 
@@ -64,25 +82,27 @@ func (c *cpu) Reset() {
 	c.regs[PC] = InitPC
 	c.virtMem.SetTable(0)
 	c.ring = 0
+	c.halted = false
 	c.interrupt.Disable()
 }
 
 func (c *cpu) tick() *Excep {
 	c.ncycle++
 	pc := c.regs[PC]
-	inst, e := c.readWord(pc)
+	inst, f, e := c.fetch(pc)
 	if e != nil {
 		return e
 	}
 
 	c.regs[PC] = pc + 4
-	if c.inst != nil {
+	if f != nil {
+		e = f(c.arch8, c, inst)
+	} else if c.inst != nil {
 		e = c.inst.I(c, inst)
-
-		if e != nil {
-			c.regs[PC] = pc // restore saved original PC
-			return e
-		}
+	}
+	if e != nil {
+		c.regs[PC] = pc // restore saved original PC
+		return e
 	}
 
 	return nil
@@ -98,25 +118,70 @@ const (
 	intFrameSize = 16
 )
 
+// halt halts the core after executing HALT. The core resumes at the
+// next instruction when it is started again.
+func (c *cpu) halt() {
+	c.halted = true
+	c.regs[PC] += 4
+}
+
 // Interrupt issues an interrupt to the core
 func (c *cpu) Interrupt(code byte) {
 	c.interrupt.Issue(code)
 }
 
+func (c *cpu) watch(addr, n uint32, write bool) {
+	if c.watcher != nil {
+		c.watcher.watch(c.index, addr, n, write)
+	}
+}
+
 func (c *cpu) readWord(addr uint32) (uint32, *Excep) {
-	return c.virtMem.ReadWord(addr, c.ring)
+	ret, e := c.virtMem.ReadWord(addr, c.ring)
+	if e == nil {
+		c.watch(addr, 4, false)
+	}
+	return ret, e
 }
 
 func (c *cpu) readByte(addr uint32) (uint8, *Excep) {
-	return c.virtMem.ReadByte(addr, c.ring)
+	ret, e := c.virtMem.ReadByte(addr, c.ring)
+	if e == nil {
+		c.watch(addr, 1, false)
+	}
+	return ret, e
+}
+
+func (c *cpu) readHalf(addr uint32) (uint16, *Excep) {
+	ret, e := c.virtMem.ReadHalf(addr, c.ring)
+	if e == nil {
+		c.watch(addr, 2, false)
+	}
+	return ret, e
+}
+
+func (c *cpu) writeHalf(addr uint32, v uint16) *Excep {
+	e := c.virtMem.WriteHalf(addr, c.ring, v)
+	if e == nil {
+		c.watch(addr, 2, true)
+	}
+	return e
 }
 
 func (c *cpu) writeWord(addr uint32, v uint32) *Excep {
-	return c.virtMem.WriteWord(addr, c.ring, v)
+	e := c.virtMem.WriteWord(addr, c.ring, v)
+	if e == nil {
+		c.watch(addr, 4, true)
+	}
+	return e
 }
 
 func (c *cpu) writeByte(addr uint32, v uint8) *Excep {
-	return c.virtMem.WriteByte(addr, c.ring, v)
+	e := c.virtMem.WriteByte(addr, c.ring, v)
+	if e == nil {
+		c.watch(addr, 1, true)
+	}
+	return e
 }
 
 // Ienter enters a interrupt routine.
@@ -148,6 +213,7 @@ func (c *cpu) Ienter(code byte, arg uint32) *Excep {
 		return e
 	}
 
+	c.intEntered = int(code)
 	c.interrupt.Disable()
 	c.regs[SP] = hsp
 	c.regs[RET] = c.regs[PC]
@@ -217,6 +283,13 @@ func (c *cpu) Iret() *Excep {
 // Tick executes one instruction, and increases the program counter
 // by 4 by default. If an exception is met, it will handle it.
 func (c *cpu) Tick() *Excep {
+	if c.tracer != nil {
+		return c.traceTick()
+	}
+	return c.tick0()
+}
+
+func (c *cpu) tick0() *Excep {
 	poll, code := c.interrupt.Poll()
 	if poll {
 		return c.Ienter(code, 0)
@@ -227,6 +300,10 @@ func (c *cpu) Tick() *Excep {
 	if e == nil {
 		return nil
 	}
+	if e == errYield {
+		c.ncycle-- // the instruction is not executed yet
+		return e
+	}
 
 	// proceed attempt failed, this is a fault.
 	c.interrupt.Issue(e.Code)       // put the fault on to interrupt
@@ -0,0 +1,267 @@
+package arch
+
+import (
+	"fmt"
+)
+
+// Reasons for a debugger to stop.
+const (
+	StopStep  = iota // a single step is completed
+	StopBreak        // a breakpoint is hit
+	StopWatch        // a watchpoint is hit
+	StopExcep        // an exception is thrown out of the machine
+)
+
+// Stop describes why the debugger stopped the machine.
+type Stop struct {
+	Reason int
+	Core   int
+
+	// Addr is the PC for breakpoints, and the virtual address of the
+	// memory access for watchpoints.
+	Addr  uint32
+	Write bool // if the watchpoint is hit by a write
+
+	Excep *CoreExcep
+}
+
+func (s *Stop) String() string {
+	switch s.Reason {
+	case StopStep:
+		return fmt.Sprintf("core %d stepped", s.Core)
+	case StopBreak:
+		return fmt.Sprintf("core %d breakpoint at %08x", s.Core, s.Addr)
+	case StopWatch:
+		op := "read"
+		if s.Write {
+			op = "write"
+		}
+		return fmt.Sprintf("core %d %s watchpoint at %08x",
+			s.Core, op, s.Addr,
+		)
+	case StopExcep:
+		return fmt.Sprintf("core %d exception: %s", s.Core, s.Excep)
+	}
+	return "unknown stop"
+}
+
+// Watchpoint watches a virtual memory range for reads or writes.
+type Watchpoint struct {
+	Addr  uint32
+	Size  uint32
+	Read  bool
+	Write bool
+}
+
+func (w *Watchpoint) hit(addr, n uint32, write bool) bool {
+	if write && !w.Write || !write && !w.Read {
+		return false
+	}
+	return addr < w.Addr+w.Size && w.Addr < addr+n
+}
+
+// Debugger controls the execution of a machine with breakpoints,
+// watchpoints and single steps.
+type Debugger struct {
+	m       *Machine
+	breaks  map[uint32]bool
+	watches []*Watchpoint
+
+	hit *Stop // the first watchpoint hit in the current tick
+
+	hist *history // undo log, nil when not recording
+
+	// resuming is true when the machine is resuming from a stop, where
+	// breakpoints at the current PCs are ignored for the next tick.
+	resuming bool
+}
+
+// NewDebugger attaches a debugger to the machine.
+func NewDebugger(m *Machine) *Debugger {
+	ret := &Debugger{
+		m:      m,
+		breaks: make(map[uint32]bool),
+	}
+	for _, c := range m.cores.cores {
+		c.watcher = ret
+	}
+	return ret
+}
+
+// Machine returns the machine that the debugger is attached to.
+func (d *Debugger) Machine() *Machine { return d.m }
+
+// Ncore returns the number of cores of the machine.
+func (d *Debugger) Ncore() int { return len(d.m.cores.cores) }
+
+// AddBreak adds a breakpoint at the given PC.
+func (d *Debugger) AddBreak(pc uint32) { d.breaks[pc] = true }
+
+// RemoveBreak removes the breakpoint at the given PC.
+func (d *Debugger) RemoveBreak(pc uint32) { delete(d.breaks, pc) }
+
+// Breaks returns the number of breakpoints.
+func (d *Debugger) Breaks() int { return len(d.breaks) }
+
+// AddWatch adds a watchpoint.
+func (d *Debugger) AddWatch(w *Watchpoint) {
+	d.watches = append(d.watches, w)
+}
+
+// RemoveWatch removes all watchpoints that starts at the address.
+func (d *Debugger) RemoveWatch(addr uint32) {
+	var ws []*Watchpoint
+	for _, w := range d.watches {
+		if w.Addr != addr {
+			ws = append(ws, w)
+		}
+	}
+	d.watches = ws
+}
+
+func (d *Debugger) watch(core byte, addr, n uint32, write bool) {
+	if d.hit != nil {
+		return
+	}
+	for _, w := range d.watches {
+		if w.hit(addr, n, write) {
+			d.hit = &Stop{
+				Reason: StopWatch,
+				Core:   int(core),
+				Addr:   addr,
+				Write:  write,
+			}
+			return
+		}
+	}
+}
+
+func (d *Debugger) checkBreaks() *Stop {
+	for i, c := range d.m.cores.cores {
+		pc := c.regs[PC]
+		if d.breaks[pc] {
+			return &Stop{Reason: StopBreak, Core: i, Addr: pc}
+		}
+	}
+	return nil
+}
+
+func (d *Debugger) takeHit() *Stop {
+	ret := d.hit
+	d.hit = nil
+	return ret
+}
+
+func excepStop(e *CoreExcep) *Stop {
+	return &Stop{Reason: StopExcep, Core: e.Core, Excep: e}
+}
+
+// Continue runs the machine for at most n ticks, or forever if n is 0,
+// until a breakpoint, a watchpoint or an exception stops it.
+// When resuming from a previous stop, breakpoints at the current PCs are
+// ignored for the first tick, so that the machine can continue from a
+// breakpoint. It returns the number of ticks executed and the stop
+// reason, which is nil when the ticks run out.
+func (d *Debugger) Continue(n int) (int, *Stop) {
+	for i := 0; n == 0 || i < n; i++ {
+		if i > 0 || !d.resuming {
+			if s := d.checkBreaks(); s != nil {
+				d.resuming = true
+				return i, s
+			}
+		}
+		d.resuming = false
+		d.beginTick()
+		e := d.m.Tick()
+		d.endTick()
+		if e != nil {
+			d.m.FlushScreen()
+			d.hit = nil
+			d.resuming = true
+			return i + 1, excepStop(e)
+		}
+		if s := d.takeHit(); s != nil {
+			d.resuming = true
+			return i + 1, s
+		}
+	}
+	return n, nil
+}
+
+func (d *Debugger) cpu(core byte) *cpu {
+	if int(core) >= len(d.m.cores.cores) {
+		panic("out of cores")
+	}
+	return d.m.cores.cores[core]
+}
+
+// Step executes one instruction on the given core. Other cores and the
+// devices are frozen during the step. Stepping a halted core stops with
+// a halt exception without executing anything.
+func (d *Debugger) Step(core byte) *Stop {
+	d.resuming = true
+	if d.cpu(core).halted {
+		return excepStop(&CoreExcep{int(core), errHalt})
+	}
+	d.beginTick()
+	d.m.phyMem.journalCore(int(core))
+	e := d.cpu(core).Tick()
+	if e != nil && e.Code == ErrHalt {
+		d.m.cores.halt(int(core))
+	}
+	d.m.phyMem.journalCore(-1)
+	d.endTick()
+	if e != nil {
+		d.hit = nil
+		return excepStop(&CoreExcep{int(core), e})
+	}
+	if s := d.takeHit(); s != nil {
+		return s
+	}
+	return &Stop{Reason: StopStep, Core: int(core)}
+}
+
+// StepOver executes one instruction on the given core like Step, but
+// when the instruction is a JAL call, it steps until the call returns,
+// at most n steps. It stops early on breakpoints, watchpoints and
+// exceptions.
+func (d *Debugger) StepOver(core byte, n int) *Stop {
+	c := d.cpu(core)
+	pc := c.regs[PC]
+	in, e := c.virtMem.ReadWord(pc, c.ring)
+	if e != nil || in>>30 != JAL {
+		return d.Step(core)
+	}
+
+	sp := c.regs[SP]
+	for i := 0; n == 0 || i < n; i++ {
+		s := d.Step(core)
+		if s.Reason != StopStep {
+			return s
+		}
+		cur := c.regs[PC]
+		if cur == pc+4 && c.regs[SP] == sp {
+			return s
+		}
+		if d.breaks[cur] {
+			return &Stop{Reason: StopBreak, Core: int(core), Addr: cur}
+		}
+	}
+	return &Stop{Reason: StopStep, Core: int(core)}
+}
+
+// Regs returns the registers of a core.
+func (d *Debugger) Regs(core byte) []uint32 {
+	return d.m.DumpRegs(core)
+}
+
+// SetReg sets the value of a register of a core.
+func (d *Debugger) SetReg(core byte, reg int, v uint32) {
+	if reg < 0 || reg >= Nreg {
+		panic("invalid register")
+	}
+	d.cpu(core).regs[reg] = v
+}
+
+// Ring returns the current ring level of a core.
+func (d *Debugger) Ring(core byte) byte { return d.cpu(core).ring }
@@ -0,0 +1,49 @@
+package arch
+
+// ReadWord reads a word from the virtual address space of a core,
+// without triggering watchpoints.
+func (d *Debugger) ReadWord(core byte, addr uint32) (uint32, error) {
+	v, e := d.cpu(core).virtMem.ReadWord(addr, 0)
+	if e != nil {
+		return 0, e
+	}
+	return v, nil
+}
+
+// WriteWord writes a word into the virtual address space of a core,
+// without triggering watchpoints.
+func (d *Debugger) WriteWord(core byte, addr, v uint32) error {
+	if e := d.cpu(core).virtMem.WriteWord(addr, 0, v); e != nil {
+		return e
+	}
+	return nil
+}
+
+// ReadBytes reads n bytes from the virtual address space of a core,
+// without triggering watchpoints.
+func (d *Debugger) ReadBytes(core byte, addr uint32, n int) (
+	[]byte, error,
+) {
+	vm := d.cpu(core).virtMem
+	ret := make([]byte, n)
+	for i := range ret {
+		b, e := vm.ReadByte(addr+uint32(i), 0)
+		if e != nil {
+			return nil, e
+		}
+		ret[i] = b
+	}
+	return ret, nil
+}
+
+// WriteBytes writes bytes into the virtual address space of a core,
+// without triggering watchpoints.
+func (d *Debugger) WriteBytes(core byte, addr uint32, bs []byte) error {
+	vm := d.cpu(core).virtMem
+	for i, b := range bs {
+		if e := vm.WriteByte(addr+uint32(i), 0, b); e != nil {
+			return e
+		}
+	}
+	return nil
+}
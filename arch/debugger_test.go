@@ -0,0 +1,96 @@
+package arch
+
+import (
+	"testing"
+
+	asminst "shanhu.io/smlvm/asm/inst"
+)
+
+func newTestMachine(t *testing.T, ncore int, insts []uint32) *Machine {
+	return newTestMachineConfig(t, &Config{Ncore: ncore}, insts)
+}
+
+func newTestMachineConfig(t *testing.T, c *Config, insts []uint32) *Machine {
+	c.InitPC = InitPC
+	m := NewMachine(c)
+	for i, in := range insts {
+		addr := InitPC + uint32(i)*4
+		if e := m.phyMem.WriteWord(addr, in); e != nil {
+			t.Fatal(e)
+		}
+	}
+	return m
+}
+
+var debuggerTestInsts = []uint32{
+	asminst.Imm(LUI, R2, 0, 1),         // 8000: lui r2 1
+	asminst.Imm(ADDI, R1, R0, 5),       // 8004: addi r1 r0 5
+	asminst.Jmp(JAL, 1),                // 8008: jal 8010
+	asminst.Sys(HALT, 0, 0),            // 800c: halt
+	asminst.Imm(SW, R1, R2, 0),         // 8010: sw r1 r2
+	asminst.Reg(SLL, PC, RET, 0, 0, 0), // 8014: mov pc ret
+	asminst.Imm(LW, R3, R2, 0),         // 8018: lw r3 r2
+	asminst.Reg(ADD, R3, R3, R3, 0, 0), // 801c: add r3 r3 r3
+}
+
+func TestDebuggerBreakWatch(t *testing.T) {
+	m := newTestMachine(t, 1, debuggerTestInsts)
+	d := NewDebugger(m)
+
+	d.AddBreak(0x8010)
+	d.AddWatch(&Watchpoint{Addr: 0x10000, Size: 4, Write: true})
+
+	_, s := d.Continue(100)
+	if s == nil || s.Reason != StopBreak || s.Addr != 0x8010 {
+		t.Fatalf("want breakpoint at 0x8010, got %v", s)
+	}
+
+	_, s = d.Continue(100)
+	if s == nil || s.Reason != StopWatch || !s.Write {
+		t.Fatalf("want write watchpoint, got %v", s)
+	}
+	if s.Addr != 0x10000 {
+		t.Errorf("watchpoint hit at %08x", s.Addr)
+	}
+	if v, err := d.ReadWord(0, 0x10000); err != nil || v != 5 {
+		t.Errorf("read memory got %d, %v", v, err)
+	}
+
+	_, s = d.Continue(100)
+	if s == nil || s.Reason != StopExcep || !IsHalt(s.Excep) {
+		t.Fatalf("want halt, got %v", s)
+	}
+}
+
+func TestDebuggerStep(t *testing.T) {
+	m := newTestMachine(t, 1, debuggerTestInsts)
+	d := NewDebugger(m)
+
+	d.Step(0)
+	d.Step(0)
+	if pc := d.Regs(0)[PC]; pc != 0x8008 {
+		t.Fatalf("pc is %08x after two steps", pc)
+	}
+
+	s := d.StepOver(0, 100)
+	if s.Reason != StopStep {
+		t.Fatalf("step over stopped with: %s", s)
+	}
+	if pc := d.Regs(0)[PC]; pc != 0x800c {
+		t.Fatalf("pc is %08x after step over", pc)
+	}
+
+	d.SetReg(0, PC, 0x8018)
+	if err := d.WriteWord(0, 0x10000, 21); err != nil {
+		t.Fatal(err)
+	}
+	d.AddWatch(&Watchpoint{Addr: 0x10000, Size: 4, Read: true})
+	s = d.Step(0)
+	if s.Reason != StopWatch || s.Write {
+		t.Fatalf("want read watchpoint, got %s", s)
+	}
+	d.Step(0)
+	if r3 := d.Regs(0)[R3]; r3 != 42 {
+		t.Errorf("r3 is %d, want 42", r3)
+	}
+}
@@ -0,0 +1,255 @@
+package arch
+
+import (
+	"log"
+	"os"
+)
+
+// DiskBlockSize is the size of a block on the block device.
+const DiskBlockSize = 512
+
+const (
+	diskCmd    = 0
+	diskState  = 1
+	diskErr    = 2
+	diskBlock  = 4  // the first block
+	diskCount  = 8  // number of blocks
+	diskAddr   = 12 // physical memory address to transfer from or to
+	diskNblock = 16 // number of blocks on the disk, set by the device
+)
+
+const (
+	diskCmdIdle  = 0
+	diskCmdRead  = 1
+	diskCmdWrite = 2
+	diskCmdFlush = 3
+
+	diskStateIdle = 0
+	diskStateBusy = 1
+)
+
+const (
+	diskErrNone = iota
+	diskErrOpen
+	diskErrCmd
+	diskErrRange
+	diskErrIO
+	diskErrMemory
+)
+
+// diskTicksPerBlock is the ticks it takes to transfer a block.
+const diskTicksPerBlock = 64
+
+// disk is a block device backed by a host disk image file. A command
+// is issued by filling in the block range and the memory address, and
+// then writing the command byte. The device transfers the blocks with
+// DMA when the command completes, and then raises the done interrupt.
+//
+// The disk image is a host input. When recording, the results of the
+// commands and the size of the disk are saved in the input log. When
+// replaying, they are taken from the log, and the image is not touched.
+type disk struct {
+	intBus intBus
+	p      *pageOffset
+	mem    *phyMemory
+	path   string
+	inputs *inputLog
+
+	state     byte
+	countDown int
+	cmd       byte
+	block     uint32
+	count     uint32
+	addr      uint32
+	err       byte // error found when the command is accepted
+
+	Core    byte
+	IntDone byte
+}
+
+func newDisk(p *page, mem *phyMemory, i intBus, path string) *disk {
+	return &disk{
+		intBus: i,
+		p:      &pageOffset{p, diskBase},
+		mem:    mem,
+		path:   path,
+
+		IntDone: IntBlock,
+	}
+}
+
+func (d *disk) nblock() uint32 {
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return 0
+	}
+	return uint32(info.Size() / DiskBlockSize)
+}
+
+func (d *disk) updateSize() {
+	if d.inputs.replaying() {
+		bs, ok := d.inputs.must(inputDiskSize)
+		if ok && len(bs) == 4 {
+			d.p.writeWord(diskNblock, Endian.Uint32(bs))
+		}
+		return
+	}
+	n := d.nblock()
+	d.p.writeWord(diskNblock, n)
+	bs := make([]byte, 4)
+	Endian.PutUint32(bs, n)
+	d.inputs.record(inputDiskSize, bs)
+}
+
+func (d *disk) interrupt(code byte) {
+	d.intBus.Interrupt(code, d.Core)
+}
+
+// check checks the command, the block range and the memory range of
+// the accepted command.
+func (d *disk) check() byte {
+	switch d.cmd {
+	case diskCmdRead, diskCmdWrite:
+	case diskCmdFlush:
+		return diskErrNone
+	default:
+		return diskErrCmd
+	}
+
+	n := d.p.readWord(diskNblock)
+	if d.block > n || d.count > n-d.block {
+		return diskErrRange
+	}
+	end := uint64(d.addr) + uint64(d.count)*DiskBlockSize
+	if end > uint64(d.mem.npage)*PageSize {
+		return diskErrMemory
+	}
+	return diskErrNone
+}
+
+func (d *disk) accept(cmd byte) {
+	d.cmd = cmd
+	d.block = d.p.readWord(diskBlock)
+	d.count = d.p.readWord(diskCount)
+	d.addr = d.p.readWord(diskAddr)
+	d.state = diskStateBusy
+	d.err = d.check()
+	switch {
+	case d.err != diskErrNone:
+		d.countDown = 0 // fails on the next tick
+	case cmd == diskCmdFlush:
+		d.countDown = diskTicksPerBlock
+	default:
+		d.countDown = diskTicksPerBlock * int(d.count)
+	}
+}
+
+func (d *disk) readBlocks(f *os.File, buf []byte) byte {
+	if _, err := f.ReadAt(buf, int64(d.block)*DiskBlockSize); err != nil {
+		log.Print(err)
+		return diskErrIO
+	}
+	return d.writeMem(buf)
+}
+
+func (d *disk) writeMem(buf []byte) byte {
+	for i, b := range buf {
+		if d.mem.WriteByte(d.addr+uint32(i), b) != nil {
+			return diskErrMemory
+		}
+	}
+	return diskErrNone
+}
+
+func (d *disk) writeBlocks(f *os.File, buf []byte) byte {
+	for i := range buf {
+		b, e := d.mem.ReadByte(d.addr + uint32(i))
+		if e != nil {
+			return diskErrMemory
+		}
+		buf[i] = b
+	}
+	if _, err := f.WriteAt(buf, int64(d.block)*DiskBlockSize); err != nil {
+		log.Print(err)
+		return diskErrIO
+	}
+	return diskErrNone
+}
+
+// run runs the accepted command. When replaying, the result is taken
+// from the input log.
+func (d *disk) run() byte {
+	if d.inputs.replaying() {
+		bs, ok := d.inputs.must(inputDisk)
+		if !ok || len(bs) == 0 {
+			return diskErrIO
+		}
+		if bs[0] == diskErrNone && d.cmd == diskCmdRead {
+			return d.writeMem(bs[1:])
+		}
+		return bs[0]
+	}
+
+	code, read := d.exec()
+	d.inputs.record(inputDisk, append([]byte{code}, read...))
+	return code
+}
+
+// exec executes the accepted command on the disk image. It returns the
+// error code, and the bytes read for a successful read.
+func (d *disk) exec() (byte, []byte) {
+	if d.err != diskErrNone {
+		return d.err, nil
+	}
+
+	f, err := os.OpenFile(d.path, os.O_RDWR, 0)
+	if err != nil {
+		log.Print(err)
+		return diskErrOpen, nil
+	}
+	defer f.Close()
+
+	if d.cmd == diskCmdFlush {
+		if err := f.Sync(); err != nil {
+			log.Print(err)
+			return diskErrIO, nil
+		}
+		return diskErrNone, nil
+	}
+
+	n := d.nblock()
+	if d.block > n || d.count > n-d.block {
+		return diskErrRange, nil
+	}
+	buf := make([]byte, d.count*DiskBlockSize)
+	if d.cmd == diskCmdRead {
+		code := d.readBlocks(f, buf)
+		if code != diskErrNone {
+			return code, nil
+		}
+		return code, buf
+	}
+	return d.writeBlocks(f, buf), nil
+}
+
+func (d *disk) Tick() {
+	switch d.state {
+	case diskStateIdle:
+		cmd := d.p.readByte(diskCmd)
+		if cmd != diskCmdIdle {
+			d.accept(cmd)
+			d.p.writeByte(diskCmd, diskCmdIdle)
+		}
+	case diskStateBusy:
+		if d.countDown > 0 {
+			d.countDown--
+		} else {
+			d.p.writeByte(diskErr, d.run())
+			d.updateSize()
+			d.state = diskStateIdle
+			d.interrupt(d.IntDone)
+		}
+	}
+
+	d.p.writeByte(diskState, d.state)
+}
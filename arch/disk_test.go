@@ -0,0 +1,194 @@
+package arch
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+var _ device = new(disk)
+
+func tempDisk(t *testing.T, nblock int) string {
+	f, err := ioutil.TempFile("", "smlvm-disk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := f.Truncate(int64(nblock) * DiskBlockSize); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+// diskRun issues a command to the disk and ticks the disk until the
+// command completes. It returns the error code of the command.
+func diskRun(t *testing.T, m *Machine, cmd byte, block, n, addr uint32) byte {
+	d := m.disk
+	d.p.writeWord(diskBlock, block)
+	d.p.writeWord(diskCount, n)
+	d.p.writeWord(diskAddr, addr)
+	d.p.writeByte(diskCmd, cmd)
+
+	d.Tick()
+	if d.p.readByte(diskState) != diskStateBusy {
+		t.Fatal("disk not busy after a command")
+	}
+	for i := 0; d.p.readByte(diskState) == diskStateBusy; i++ {
+		if i > diskTicksPerBlock*int(n+1)+1 {
+			t.Fatal("disk command not finishing")
+		}
+		d.Tick()
+	}
+
+	intr := m.cores.cores[0].interrupt
+	if intr.readByte(intPending+IntBlock/8)&(1<<(IntBlock%8)) == 0 {
+		t.Error("completion interrupt not issued")
+	}
+	intr.Clear(IntBlock)
+	return d.p.readByte(diskErr)
+}
+
+func TestDisk(t *testing.T) {
+	path := tempDisk(t, 4)
+	defer os.Remove(path)
+
+	m := NewMachine(&Config{Disk: path})
+	if n := m.disk.p.readWord(diskNblock); n != 4 {
+		t.Errorf("got %d blocks, want 4", n)
+	}
+
+	const src = 0x10000
+	const dest = 0x20000
+	data := make([]byte, 2*DiskBlockSize)
+	for i := range data {
+		data[i] = byte(i * 7)
+		m.phyMem.WriteByte(src+uint32(i), data[i])
+	}
+
+	if e := diskRun(t, m, diskCmdWrite, 1, 2, src); e != diskErrNone {
+		t.Fatalf("write got error %d", e)
+	}
+	if e := diskRun(t, m, diskCmdFlush, 0, 0, 0); e != diskErrNone {
+		t.Fatalf("flush got error %d", e)
+	}
+
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bs[DiskBlockSize:3*DiskBlockSize], data) {
+		t.Error("disk image content mismatch")
+	}
+
+	if e := diskRun(t, m, diskCmdRead, 2, 1, dest); e != diskErrNone {
+		t.Fatalf("read got error %d", e)
+	}
+	for i := 0; i < DiskBlockSize; i++ {
+		b, _ := m.phyMem.ReadByte(dest + uint32(i))
+		if want := data[DiskBlockSize+i]; b != want {
+			t.Fatalf("byte %d: got %d, want %d", i, b, want)
+		}
+	}
+
+	if e := diskRun(t, m, diskCmdRead, 3, 2, dest); e != diskErrRange {
+		t.Errorf("read out of range, got error %d", e)
+	}
+	if e := diskRun(t, m, 9, 0, 1, dest); e != diskErrCmd {
+		t.Errorf("invalid command, got error %d", e)
+	}
+}
+
+func TestDiskBadRequest(t *testing.T) {
+	path := tempDisk(t, 4)
+	defer os.Remove(path)
+
+	m := NewMachine(&Config{Disk: path, MemSize: 0x100000})
+	for _, test := range []struct {
+		block, count, addr uint32
+		want               byte
+	}{
+		{0, 0xffffffff, 0x10000, diskErrRange},
+		{0xffffffff, 2, 0x10000, diskErrRange},
+		{0, 2, 0xfff00, diskErrMemory},
+		{0, 1, 0xffffff00, diskErrMemory},
+	} {
+		d := m.disk
+		d.p.writeWord(diskBlock, test.block)
+		d.p.writeWord(diskCount, test.count)
+		d.p.writeWord(diskAddr, test.addr)
+		d.p.writeByte(diskCmd, diskCmdRead)
+
+		d.Tick() // accepts the command
+		d.Tick() // fails the command
+		if d.p.readByte(diskState) != diskStateIdle {
+			t.Errorf("%+v: disk still busy", test)
+			continue
+		}
+		if e := d.p.readByte(diskErr); e != test.want {
+			t.Errorf("%+v: got error %d, want %d", test, e, test.want)
+		}
+	}
+}
+
+func TestDiskReplay(t *testing.T) {
+	path := tempDisk(t, 4)
+	defer os.Remove(path)
+
+	data := make([]byte, 4*DiskBlockSize)
+	for i := range data {
+		data[i] = byte(i * 3)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	const dest = 0x20000
+	buf := new(bytes.Buffer)
+	m := NewMachine(&Config{Disk: path, Record: buf})
+	if e := diskRun(t, m, diskCmdRead, 1, 2, dest); e != diskErrNone {
+		t.Fatalf("read got error %d", e)
+	}
+	if e := diskRun(t, m, diskCmdWrite, 0, 1, dest); e != diskErrNone {
+		t.Fatalf("write got error %d", e)
+	}
+	if err := m.InputLogErr(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the image changes after recording
+	if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := ReadInputLog(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewMachine(&Config{Disk: path, Replay: l})
+	if n := r.disk.p.readWord(diskNblock); n != 4 {
+		t.Errorf("replay got %d blocks, want 4", n)
+	}
+	if e := diskRun(t, r, diskCmdRead, 1, 2, dest); e != diskErrNone {
+		t.Fatalf("replay read got error %d", e)
+	}
+	for i := 0; i < 2*DiskBlockSize; i++ {
+		b, _ := r.phyMem.ReadByte(dest + uint32(i))
+		if want := data[DiskBlockSize+i]; b != want {
+			t.Fatalf("byte %d: got %d, want %d", i, b, want)
+		}
+	}
+	if e := diskRun(t, r, diskCmdWrite, 0, 1, dest); e != diskErrNone {
+		t.Fatalf("replay write got error %d", e)
+	}
+	if err := r.InputLogErr(); err != nil {
+		t.Fatal(err)
+	}
+
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bs) != 0 {
+		t.Error("replay wrote to the disk image")
+	}
+}
@@ -0,0 +1,157 @@
+// Package display provides a pixel display device with a palette and
+// two frame buffers in the memory of the machine.
+package display
+
+import (
+	"image"
+	"image/color"
+
+	"shanhu.io/smlvm/arch/vpc"
+	"shanhu.io/smlvm/coder"
+)
+
+const (
+	// Width is the number of pixels in a row.
+	Width = 320
+
+	// Height is the number of rows.
+	Height = 200
+
+	// FrameSize is the size of a frame buffer, a byte per pixel.
+	FrameSize = Width * Height
+
+	// NColor is the number of colors in the palette.
+	NColor = 256
+)
+
+// Commands of a display request.
+const (
+	cmdInfo    = 0 // returns the size and the back buffer address
+	cmdMap     = 1 // maps the frame buffers at an address
+	cmdPalette = 2 // sets colors in the palette
+	cmdPresent = 3 // shows the back buffer and swaps the buffers
+)
+
+// Memory is the physical memory that holds the frame buffers.
+type Memory interface {
+	// ReadAt reads len(p) bytes at addr. It returns false if the bytes
+	// are out of range.
+	ReadAt(p []byte, addr uint32) bool
+}
+
+// Display is a pixel display device. The guest maps two frame buffers
+// of FrameSize bytes in its memory, back to back, where each byte is a
+// palette index of a pixel, row by row. The guest draws into the back
+// buffer, and presents it with a request, which renders the buffer and
+// makes the other one the back buffer.
+type Display struct {
+	mem Memory
+	r   Render
+
+	addr    uint32
+	mapped  bool
+	front   uint32 // index of the front buffer
+	palette color.Palette
+}
+
+func defaultPalette() color.Palette {
+	ret := make(color.Palette, NColor)
+	for i := range ret {
+		ret[i] = color.RGBA{uint8(i), uint8(i), uint8(i), 0xff}
+	}
+	return ret
+}
+
+// New creates a new display that reads the frame buffers from mem, and
+// renders the frames on r. The palette is grayscale by default.
+func New(mem Memory, r Render) *Display {
+	if r == nil {
+		panic("creating display with nil render")
+	}
+	return &Display{
+		mem:     mem,
+		r:       r,
+		palette: defaultPalette(),
+	}
+}
+
+func (d *Display) back() uint32 {
+	if !d.mapped {
+		return 0
+	}
+	return d.addr + (1-d.front)*FrameSize
+}
+
+func u32(v uint32) []byte {
+	enc := coder.NewEncoder()
+	enc.U32(v)
+	return enc.Bytes()
+}
+
+func (d *Display) setPalette(dec *coder.Decoder) int32 {
+	start := int(dec.U8())
+	n := int(dec.U8())
+	bs := dec.Bytes(n * 3)
+	if dec.Err != nil || start+n > NColor {
+		return vpc.ErrInvalidArg
+	}
+	for i := 0; i < n; i++ {
+		rgb := bs[i*3 : i*3+3]
+		d.palette[start+i] = color.RGBA{rgb[0], rgb[1], rgb[2], 0xff}
+	}
+	return 0
+}
+
+func (d *Display) present() ([]byte, int32) {
+	if !d.mapped {
+		return nil, vpc.ErrInvalidArg
+	}
+
+	f := image.NewPaletted(image.Rect(0, 0, Width, Height), nil)
+	if !d.mem.ReadAt(f.Pix, d.back()) {
+		return nil, vpc.ErrMemory
+	}
+	f.Palette = make(color.Palette, NColor)
+	copy(f.Palette, d.palette)
+
+	d.front = 1 - d.front
+	d.r.Render(f)
+	return u32(d.back()), 0
+}
+
+// Handle handles a display request. A request starts with a command
+// byte. cmdInfo returns the width, the height and the address of the
+// back buffer as uint32s. cmdMap takes the uint32 address of the frame
+// buffers. cmdPalette takes the first index to set, the number of
+// colors, and then the RGB bytes of each color. cmdPresent returns the
+// address of the new back buffer.
+func (d *Display) Handle(req []byte) ([]byte, int32) {
+	dec := coder.NewDecoder(req)
+	cmd := dec.U8()
+	if dec.Err != nil {
+		return nil, vpc.ErrInvalidArg
+	}
+
+	switch cmd {
+	case cmdInfo:
+		enc := coder.NewEncoder()
+		enc.U32(Width)
+		enc.U32(Height)
+		enc.U32(d.back())
+		return enc.Bytes(), 0
+	case cmdMap:
+		addr := dec.U32()
+		if dec.Err != nil || addr > ^uint32(0)-2*FrameSize+1 {
+			return nil, vpc.ErrInvalidArg
+		}
+		d.addr = addr
+		d.mapped = true
+		d.front = 0
+		return nil, 0
+	case cmdPalette:
+		return nil, d.setPalette(dec)
+	case cmdPresent:
+		return d.present()
+	}
+	return nil, vpc.ErrInvalidArg
+}
@@ -0,0 +1,146 @@
+package display
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"shanhu.io/smlvm/arch/vpc"
+	"shanhu.io/smlvm/coder"
+)
+
+type testMem []byte
+
+func (m testMem) ReadAt(p []byte, addr uint32) bool {
+	if uint64(addr)+uint64(len(p)) > uint64(len(m)) {
+		return false
+	}
+	copy(p, m[addr:])
+	return true
+}
+
+type testRender struct{ frames []*image.Paletted }
+
+func (r *testRender) Render(f *image.Paletted) {
+	r.frames = append(r.frames, f)
+}
+
+func u32Req(cmd byte, v uint32) []byte {
+	enc := coder.NewEncoder()
+	enc.U8(cmd)
+	enc.U32(v)
+	return enc.Bytes()
+}
+
+func TestDisplay(t *testing.T) {
+	const base = 0x100
+	mem := make(testMem, base+2*FrameSize)
+	r := new(testRender)
+	d := New(mem, r)
+
+	if _, code := d.Handle([]byte{cmdPresent}); code != vpc.ErrInvalidArg {
+		t.Errorf("present before mapping got code %d", code)
+	}
+	if _, code := d.Handle(u32Req(cmdMap, base)); code != 0 {
+		t.Fatalf("map got code %d", code)
+	}
+	resp, code := d.Handle([]byte{cmdInfo})
+	if code != 0 {
+		t.Fatalf("info got code %d", code)
+	}
+	dec := coder.NewDecoder(resp)
+	w, h, back := dec.U32(), dec.U32(), dec.U32()
+	if w != Width || h != Height || back != base+FrameSize {
+		t.Errorf("got info %d, %d, %x", w, h, back)
+	}
+
+	req := []byte{cmdPalette, 7, 1, 0x10, 0x20, 0x30}
+	if _, code := d.Handle(req); code != 0 {
+		t.Fatalf("palette got code %d", code)
+	}
+	mem[back+Width+2] = 7 // pixel (2, 1)
+
+	resp, code = d.Handle([]byte{cmdPresent})
+	if code != 0 {
+		t.Fatalf("present got code %d", code)
+	}
+	if got := coder.NewDecoder(resp).U32(); got != base {
+		t.Errorf("got back buffer %x, want %x", got, base)
+	}
+	if len(r.frames) != 1 {
+		t.Fatalf("got %d frames", len(r.frames))
+	}
+	f := r.frames[0]
+	want := color.RGBA{0x10, 0x20, 0x30, 0xff}
+	if got := f.At(2, 1); got != want {
+		t.Errorf("got pixel %v, want %v", got, want)
+	}
+	if got := f.ColorIndexAt(1, 1); got != 0 {
+		t.Errorf("got color index %d, want 0", got)
+	}
+
+	// the state comes back on a new display
+	d2 := New(mem, r)
+	d2.SetState(d.State())
+	if _, code := d2.Handle([]byte{cmdPresent}); code != 0 {
+		t.Fatalf("present got code %d", code)
+	}
+	if got := r.frames[1].At(2, 1); got != (color.RGBA{0, 0, 0, 0xff}) {
+		t.Errorf("got pixel %v in the other buffer", got)
+	}
+	if got := r.frames[1].Palette[7]; got != want {
+		t.Errorf("got color %v, want %v", got, want)
+	}
+}
+
+func TestDisplayBadRequest(t *testing.T) {
+	d := New(make(testMem, FrameSize), new(testRender))
+	for _, req := range [][]byte{
+		nil,
+		{9},
+		{cmdMap, 0},
+		u32Req(cmdMap, 0xffffffff-FrameSize),
+		{cmdPalette, 255, 2, 0, 0, 0, 0, 0, 0},
+		{cmdPalette, 0, 1, 0},
+	} {
+		if _, code := d.Handle(req); code != vpc.ErrInvalidArg {
+			t.Errorf("request %v got code %d", req, code)
+		}
+	}
+
+	if _, code := d.Handle(u32Req(cmdMap, 0)); code != 0 {
+		t.Fatalf("map got code %d", code)
+	}
+	if _, code := d.Handle([]byte{cmdPresent}); code != vpc.ErrMemory {
+		t.Errorf("present out of memory got code %d", code)
+	}
+}
+
+func TestPNGRender(t *testing.T) {
+	dir := t.TempDir()
+	r := &PNGRender{Dir: dir}
+
+	f := image.NewPaletted(image.Rect(0, 0, 2, 1), defaultPalette())
+	f.Pix[1] = 200
+	r.Render(f)
+	r.Render(f)
+	if r.Err != nil || r.N != 2 {
+		t.Fatalf("got %d frames, err=%v", r.N, r.Err)
+	}
+
+	bs, err := os.ReadFile(filepath.Join(dir, "frame-0001.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(bs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := img.At(1, 0), f.At(1, 0); got != want {
+		t.Errorf("got pixel %v, want %v", got, want)
+	}
+}
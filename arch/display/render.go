@@ -0,0 +1,49 @@
+package display
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+)
+
+// Render is an interface that renders the frames of a display.
+type Render interface {
+	// Render renders a presented frame. The frame is not changed by
+	// the display afterwards.
+	Render(f *image.Paletted)
+}
+
+// PNGRender writes each frame into a numbered PNG file in a directory,
+// as frame-0000.png, frame-0001.png, and so on.
+type PNGRender struct {
+	Dir string
+	N   int   // number of frames rendered
+	Err error // the first error met; frames are dropped after it
+}
+
+func (r *PNGRender) write(f *image.Paletted) error {
+	name := filepath.Join(r.Dir, fmt.Sprintf("frame-%04d.png", r.N))
+	out, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(out, f); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Render writes a frame into the next PNG file.
+func (r *PNGRender) Render(f *image.Paletted) {
+	if r.Err != nil {
+		return
+	}
+	if err := r.write(f); err != nil {
+		r.Err = err
+		return
+	}
+	r.N++
+}
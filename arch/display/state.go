@@ -0,0 +1,44 @@
+package display
+
+import (
+	"image/color"
+)
+
+// State is the state of a display that the guest sets, which is saved
+// in machine snapshots.
+type State struct {
+	Addr    uint32
+	Mapped  bool
+	Front   uint32
+	Palette []byte // RGB bytes of each color
+}
+
+// State returns the state of the display.
+func (d *Display) State() *State {
+	ret := &State{
+		Addr:   d.addr,
+		Mapped: d.mapped,
+		Front:  d.front,
+	}
+	for _, c := range d.palette {
+		r, g, b, _ := c.RGBA()
+		ret.Palette = append(ret.Palette,
+			uint8(r>>8), uint8(g>>8), uint8(b>>8),
+		)
+	}
+	return ret
+}
+
+// SetState restores the state of the display.
+func (d *Display) SetState(s *State) {
+	d.addr = s.Addr
+	d.mapped = s.Mapped
+	d.front = s.Front & 0x1
+	for i := range d.palette {
+		if i*3+3 > len(s.Palette) {
+			break
+		}
+		rgb := s.Palette[i*3 : i*3+3]
+		d.palette[i] = color.RGBA{rgb[0], rgb[1], rgb[2], 0xff}
+	}
+}
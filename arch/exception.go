@@ -38,10 +38,12 @@ const (
 	ErrPageReadonly = 7
 	ErrPanic        = 8
 	ErrSleep        = 9
+	ErrDivZero      = 10
 
 	IntSerial = 16
 	IntROM    = 17
 	IntSwap   = 18
+	IntBlock  = 19
 )
 
 var (
@@ -52,6 +54,7 @@ var (
 	errMisalign = newExcep(ErrMisalign, "address misalign")
 	errPanic    = newExcep(ErrPanic, "panic")
 	errSleep    = newExcep(ErrSleep, "sleep")
+	errDivZero  = newExcep(ErrDivZero, "divide by zero")
 )
 
 func newPageFault(va uint32) *Excep {
@@ -0,0 +1,228 @@
+package arch
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"time"
+)
+
+// inputLogVersion is the version of the input log format.
+const inputLogVersion = 1
+
+// Kinds of the logged inputs.
+const (
+	inputClock      = 1  // wall clock reading, in unix nanoseconds
+	inputPerfClock  = 2  // monotonic clock reading, in nanoseconds
+	inputConsole    = 3  // console input byte, empty for the end of input
+	inputClick      = 4  // screen click, line and column
+	inputTableClick = 5  // table click, the position
+	inputROM        = 6  // rom error code, followed by the bytes read
+	inputKey        = 7  // key code, modifiers, and 1 for down
+	inputText       = 8  // text typed on the keyboard
+	inputDisk       = 9  // disk error code, followed by the bytes read
+	inputDiskSize   = 10 // number of blocks on the disk
+)
+
+type inputLogHeader struct {
+	Version int
+	Seed    int64
+}
+
+type inputEvent struct {
+	Tick uint64 // device ticks before the input is delivered
+	Kind byte
+	Data []byte
+}
+
+// InputLog is a log of the nondeterministic inputs of a machine, which
+// can be replayed to run the machine the same way again.
+type InputLog struct {
+	seed   int64
+	events []*inputEvent
+}
+
+// ReadInputLog reads an input log recorded with Config.Record.
+func ReadInputLog(r io.Reader) (*InputLog, error) {
+	dec := gob.NewDecoder(r)
+	h := new(inputLogHeader)
+	if err := dec.Decode(h); err != nil {
+		return nil, err
+	}
+	if h.Version != inputLogVersion {
+		return nil, fmt.Errorf(
+			"unsupported input log version: %d", h.Version,
+		)
+	}
+
+	ret := &InputLog{seed: h.Seed}
+	for {
+		e := new(inputEvent)
+		if err := dec.Decode(e); err == io.EOF {
+			return ret, nil
+		} else if err != nil {
+			return nil, err
+		}
+		ret.events = append(ret.events, e)
+	}
+}
+
+// inputLog records or replays the inputs of a machine. The methods
+// are no-ops on a nil inputLog.
+type inputLog struct {
+	now uint64 // device ticks
+
+	enc    *gob.Encoder // when recording
+	replay bool
+	events []*inputEvent // events left when replaying
+
+	err error
+}
+
+func newRecorder(w io.Writer, seed int64) *inputLog {
+	ret := &inputLog{enc: gob.NewEncoder(w)}
+	ret.err = ret.enc.Encode(&inputLogHeader{inputLogVersion, seed})
+	return ret
+}
+
+func newReplayer(l *InputLog) *inputLog {
+	return &inputLog{replay: true, events: l.events}
+}
+
+func (l *inputLog) replaying() bool { return l != nil && l.replay }
+
+func (l *inputLog) tick() {
+	if l == nil {
+		return
+	}
+	l.now++
+	if l.replay && l.err == nil && len(l.events) > 0 {
+		if e := l.events[0]; e.Tick < l.now {
+			l.diverge(e.Kind)
+		}
+	}
+}
+
+func (l *inputLog) record(kind byte, data []byte) {
+	if l == nil || l.enc == nil || l.err != nil {
+		return
+	}
+	l.err = l.enc.Encode(&inputEvent{l.now, kind, data})
+}
+
+// next takes the next input if it is of kind and delivered on this
+// tick.
+func (l *inputLog) next(kind byte) ([]byte, bool) {
+	if !l.replaying() || len(l.events) == 0 {
+		return nil, false
+	}
+	e := l.events[0]
+	if e.Kind != kind || e.Tick != l.now {
+		return nil, false
+	}
+	l.events = l.events[1:]
+	return e.Data, true
+}
+
+// diverge marks that the replay no longer follows the log, as an input
+// of kind is not consumed as recorded.
+func (l *inputLog) diverge(kind byte) {
+	if l.err == nil {
+		l.err = fmt.Errorf("replay diverged on tick %d: input kind %d",
+			l.now, kind,
+		)
+	}
+}
+
+// must takes an input that is read on demand.
+func (l *inputLog) must(kind byte) ([]byte, bool) {
+	bs, ok := l.next(kind)
+	if !ok {
+		l.diverge(kind)
+	}
+	return bs, ok
+}
+
+func (l *inputLog) wrapClock(now func() time.Time) func() time.Time {
+	if l == nil {
+		return now
+	}
+	return func() time.Time {
+		if l.replay {
+			bs, ok := l.must(inputClock)
+			if !ok || len(bs) != 8 {
+				return time.Unix(0, 0)
+			}
+			return time.Unix(0, int64(Endian.Uint64(bs)))
+		}
+		t := now()
+		bs := make([]byte, 8)
+		Endian.PutUint64(bs, uint64(t.UnixNano()))
+		l.record(inputClock, bs)
+		return t
+	}
+}
+
+func (l *inputLog) wrapPerfClock(
+	now func() time.Duration,
+) func() time.Duration {
+	if l == nil || now == nil {
+		return now
+	}
+	return func() time.Duration {
+		if l.replay {
+			bs, ok := l.must(inputPerfClock)
+			if !ok || len(bs) != 8 {
+				return 0
+			}
+			return time.Duration(Endian.Uint64(bs))
+		}
+		d := now()
+		bs := make([]byte, 8)
+		Endian.PutUint64(bs, uint64(d))
+		l.record(inputPerfClock, bs)
+		return d
+	}
+}
+
+// InputLogErr returns the error of recording the inputs, or the error
+// when a replay diverges from the input log.
+func (m *Machine) InputLogErr() error {
+	if m.inputs == nil {
+		return nil
+	}
+	return m.inputs.err
+}
+
+// deliverClicks delivers the clicks and the keyboard inputs of this
+// tick in the input log.
+func (m *Machine) deliverClicks() {
+	for m.inputs.replaying() {
+		if bs, ok := m.inputs.next(inputClick); ok {
+			if m.clicks != nil && len(bs) == 2 {
+				m.click(bs[0], bs[1])
+			}
+			continue
+		}
+		if bs, ok := m.inputs.next(inputKey); ok {
+			if m.keys != nil && len(bs) == 4 {
+				code := uint16(bs[0]) | uint16(bs[1])<<8
+				m.keys.Press(code, bs[2], bs[3] != 0)
+			}
+			continue
+		}
+		if bs, ok := m.inputs.next(inputText); ok {
+			if m.keys != nil {
+				m.keys.Type(string(bs))
+			}
+			continue
+		}
+		bs, ok := m.inputs.next(inputTableClick)
+		if !ok {
+			return
+		}
+		if m.table != nil && len(bs) == 1 {
+			m.clickTable(bs[0])
+		}
+	}
+}
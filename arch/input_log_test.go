@@ -0,0 +1,168 @@
+package arch
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"shanhu.io/smlvm/arch/keyboard"
+	"shanhu.io/smlvm/arch/table"
+	asminst "shanhu.io/smlvm/asm/inst"
+)
+
+type nopTable struct{}
+
+func (nopTable) Act(a *table.Action) {}
+
+type nopScreen struct{}
+
+func (nopScreen) NeedUpdate() bool              { return false }
+func (nopScreen) UpdateText(m map[uint32]byte)  {}
+func (nopScreen) UpdateColor(m map[uint32]byte) {}
+
+// inputLogTestInsts saves the cycle when each console input byte
+// arrives, with the byte in the lowest byte, and halts at the end of
+// the input.
+var inputLogTestInsts = []uint32{
+	asminst.Imm(ADDI, R1, R0, 0x2000), // 8000: addi r1 r0 0x2000
+	asminst.Imm(LUI, SP, 0, 1),        // 8004: lui sp 1
+	asminst.Imm(LBU, R2, R1, 5),       // 8008: .loop: lbu r2 r1 5
+	asminst.Br(BEQ, R2, R0, -2),       // 800c: beq r2 r0 .loop
+	asminst.Imm(ADDI, R3, R0, 2),      // 8010: addi r3 r0 2
+	asminst.Br(BEQ, R2, R3, 8),        // 8014: beq r2 r3 .end
+	asminst.Imm(LBU, R2, R1, 4),       // 8018: lbu r2 r1 4
+	asminst.Imm(SB, R0, R1, 5),        // 801c: sb r0 r1 5
+	asminst.Imm(ADDI, R3, R0, NCYCLE), // 8020: addi r3 r0 NCYCLE
+	asminst.Sys(SYSINFO, R3, R4),      // 8024: sysinfo r3 r4
+	asminst.Imm(SW, R3, SP, 0),        // 8028: sw r3 sp
+	asminst.Imm(SB, R2, SP, 0),        // 802c: sb r2 sp
+	asminst.Imm(ADDI, SP, SP, 4),      // 8030: addi sp sp 4
+	asminst.Jmp(J, -12),               // 8034: j .loop
+	asminst.Sys(HALT, 0, 0),           // 8038: .end: halt
+}
+
+func readClock(t *testing.T, m *Machine) []byte {
+	resp, code, e := m.calls.call(1, serviceClock, nil, 8)
+	if e != nil || code != 0 {
+		t.Fatalf("read clock failed: code=%d, e=%v", code, e)
+	}
+	return resp
+}
+
+func TestInputLog(t *testing.T) {
+	const input = "hello"
+
+	buf := new(bytes.Buffer)
+	m := newTestMachineConfig(t, &Config{
+		Input:  strings.NewReader(input),
+		Table:  nopTable{},
+		Record: buf,
+	}, inputLogTestInsts)
+	m.ClickTable(3)
+	n, e := m.Run(0)
+	if !IsHalt(e) {
+		t.Fatalf("want halt, got %v", e)
+	}
+	clock := readClock(t, m)
+	if err := m.InputLogErr(); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := ReadInputLog(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newTestMachineConfig(t, &Config{
+		Input:  strings.NewReader("ignored"),
+		Table:  nopTable{},
+		Replay: l,
+	}, inputLogTestInsts)
+	r.ClickTable(5) // ignored
+	rn, e := r.Run(0)
+	if !IsHalt(e) {
+		t.Fatalf("replay: want halt, got %v", e)
+	}
+	if rn != n {
+		t.Errorf("replay ran %d ticks, want %d", rn, n)
+	}
+	for i := range input {
+		addr := uint32(0x10000 + 4*i)
+		want, _ := m.phyMem.ReadWord(addr)
+		got, _ := r.phyMem.ReadWord(addr)
+		if got != want {
+			t.Errorf("byte %d: got %08x, want %08x", i, got, want)
+		}
+	}
+	if r.calls.queueLen() != 1 {
+		t.Fatalf("got %d messages, want 1", r.calls.queueLen())
+	}
+	msg := r.calls.queue.Front().Value.(*callsMessage)
+	if !bytes.Equal(msg.p, []byte{3}) {
+		t.Errorf("got click %v, want [3]", msg.p)
+	}
+	if got := readClock(t, r); !bytes.Equal(got, clock) {
+		t.Errorf("got clock %v, want %v", got, clock)
+	}
+	if err := r.InputLogErr(); err != nil {
+		t.Fatal(err)
+	}
+
+	readClock(t, r)
+	if r.InputLogErr() == nil {
+		t.Error("replay should diverge on a clock read not in the log")
+	}
+}
+
+func queuedMessages(m *Machine) [][]byte {
+	var ret [][]byte
+	for e := m.calls.queue.Front(); e != nil; e = e.Next() {
+		ret = append(ret, e.Value.(*callsMessage).p)
+	}
+	return ret
+}
+
+func TestInputLogKeyboard(t *testing.T) {
+	insts := []uint32{
+		asminst.Jmp(J, -1), // 8000: j 8000
+	}
+
+	buf := new(bytes.Buffer)
+	m := newTestMachineConfig(t, &Config{
+		Screen: nopScreen{},
+		Record: buf,
+	}, insts)
+	m.keys.SetMode(keyboard.ModeKeys | keyboard.ModeText)
+	m.Run(3)
+	m.KeyPress(keyboard.CodeF1, keyboard.ModAlt, true)
+	m.TypeText("hi")
+	m.Run(2)
+	m.KeyPress(keyboard.CodeF1, keyboard.ModAlt, false)
+	m.Run(1)
+	want := queuedMessages(m)
+	if len(want) != 3 {
+		t.Fatalf("got %d messages, want 3", len(want))
+	}
+
+	l, err := ReadInputLog(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newTestMachineConfig(t, &Config{
+		Screen: nopScreen{},
+		Replay: l,
+	}, insts)
+	r.keys.SetMode(keyboard.ModeKeys | keyboard.ModeText)
+	r.Run(6)
+	got := queuedMessages(r)
+	if len(got) != len(want) {
+		t.Fatalf("replay got %d messages, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !bytes.Equal(got[i], want[i]) {
+			t.Errorf("message %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+	if err := r.InputLogErr(); err != nil {
+		t.Error(err)
+	}
+}
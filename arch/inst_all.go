@@ -9,23 +9,37 @@ type instArch8 struct {
 	jmp instJmp
 }
 
-// I executes an arch8 instructino
-func (i *instArch8) I(cpu *cpu, in uint32) *Excep {
+// instFunc executes an arch8 instruction of a particular class.
+type instFunc func(i *instArch8, cpu *cpu, in uint32) *Excep
+
+func execReg(i *instArch8, c *cpu, in uint32) *Excep { return i.reg.I(c, in) }
+func execImm(i *instArch8, c *cpu, in uint32) *Excep { return i.imm.I(c, in) }
+func execBr(i *instArch8, c *cpu, in uint32) *Excep  { return i.br.I(c, in) }
+func execSys(i *instArch8, c *cpu, in uint32) *Excep { return i.sys.I(c, in) }
+func execJmp(i *instArch8, c *cpu, in uint32) *Excep { return i.jmp.I(c, in) }
+
+// predecode returns the function that executes the instruction.
+func predecode(in uint32) instFunc {
 	if (in >> 31) == 0 {
 		op := (in >> 24) & 0xff // (32:24]
 		switch {
 		case op == 0: // op == 0
-			return i.reg.I(cpu, in)
+			return execReg
 		case op < 32: // op in (0, 32)
-			return i.imm.I(cpu, in)
+			return execImm
 		case op < 64: // op in [32, 64)
-			return i.br.I(cpu, in)
+			return execBr
 		case op < 128: // op in [64, 128)
-			return i.sys.I(cpu, in)
+			return execSys
 		default:
 			panic("bug")
 		}
 	}
 
-	return i.jmp.I(cpu, in)
+	return execJmp
+}
+
+// I executes an arch8 instructino
+func (i *instArch8) I(cpu *cpu, in uint32) *Excep {
+	return predecode(in)(i, cpu, in)
 }
@@ -0,0 +1,32 @@
+package arch
+
+// atomicOp executes an atomic read-modify-write on the word at addr,
+// and returns the old word. The read and the write are in the same
+// instruction, and atomic instructions are serialized even when the
+// cores run in parallel.
+func atomicOp(cpu *cpu, funct, d, addr, v uint32) (uint32, *Excep) {
+	cpu.phyMem.atomicMu.Lock()
+	defer cpu.phyMem.atomicMu.Unlock()
+
+	old, e := cpu.readWord(addr)
+	if e != nil {
+		return 0, e
+	}
+
+	switch funct {
+	case CAS:
+		if old != d {
+			return old, nil
+		}
+	case AMOADD:
+		v += old
+	case AMOSWAP:
+	default:
+		panic("not an atomic inst")
+	}
+
+	if e := cpu.writeWord(addr, v); e != nil {
+		return 0, e
+	}
+	return old, nil
+}
@@ -0,0 +1,99 @@
+package arch
+
+import (
+	"testing"
+
+	asminst "shanhu.io/smlvm/asm/inst"
+)
+
+func TestInstAtomic(t *testing.T) {
+	m := newPhyMemory(PageSize * 32)
+	cpu := newCPU(m, nil, new(instReg), 0)
+	const addr = 0x10000
+
+	tst := func(op, mem, d, v, wantD, wantMem uint32) {
+		cpu.Reset()
+		m.WriteWord(InitPC, asminst.Reg(op, R1, R2, R3, 0, 0))
+		m.WriteWord(addr, mem)
+		cpu.regs[R1] = d
+		cpu.regs[R2] = addr
+		cpu.regs[R3] = v
+		if e := cpu.Tick(); e != nil {
+			t.Fatal("unexpected exception")
+		}
+
+		if got := cpu.regs[R1]; got != wantD {
+			t.Errorf("op %d: got r1=%d, expect %d", op, got, wantD)
+		}
+		if got, _ := m.ReadWord(addr); got != wantMem {
+			t.Errorf("op %d: got mem=%d, expect %d", op, got, wantMem)
+		}
+	}
+
+	tst(CAS, 5, 5, 7, 5, 7)
+	tst(CAS, 5, 6, 7, 5, 5)
+	tst(AMOADD, 5, 0, 3, 5, 8)
+	tst(AMOADD, 5, 0, 0xffffffff, 5, 4)
+	tst(AMOSWAP, 5, 0, 9, 5, 9)
+}
+
+func testContention(t *testing.T, insts []uint32, addr, want uint32) {
+	const ncore = 4
+	m := newTestMachine(t, ncore, insts)
+	if _, e := m.Run(100000); e != nil {
+		t.Fatalf("unexpected exception: %v", e)
+	}
+	got, e := m.phyMem.ReadWord(addr)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if got != want*ncore {
+		t.Errorf("got %d, want %d", got, want*ncore)
+	}
+}
+
+func TestAtomicContention(t *testing.T) {
+	const dec = 0xffff // -1
+
+	// increments a counter with cas retry loops
+	testContention(t, []uint32{
+		asminst.Imm(LUI, R2, 0, 1),         // 8000: lui r2 1
+		asminst.Imm(ADDI, R3, R0, 100),     // 8004: addi r3 r0 100
+		asminst.Imm(LW, R1, R2, 0),         // 8008: .loop: lw r1 r2
+		asminst.Imm(ADDI, R4, R1, 1),       // 800c: addi r4 r1 1
+		asminst.Reg(CAS, R1, R2, R4, 0, 0), // 8010: cas r1 r2 r4
+		asminst.Imm(ADDI, R4, R4, dec),     // 8014: addi r4 r4 -1
+		asminst.Br(BNE, R1, R4, -5),        // 8018: bne r1 r4 .loop
+		asminst.Imm(ADDI, R3, R3, dec),     // 801c: addi r3 r3 -1
+		asminst.Br(BNE, R3, R0, -7),        // 8020: bne r3 r0 .loop
+		asminst.Jmp(J, -1),                 // 8024: .end: j .end
+	}, 0x10000, 100)
+
+	// increments a counter with amoadd
+	testContention(t, []uint32{
+		asminst.Imm(LUI, R2, 0, 1),            // 8000: lui r2 1
+		asminst.Imm(ADDI, R3, R0, 100),        // 8004: addi r3 r0 100
+		asminst.Imm(ADDI, R4, R0, 1),          // 8008: addi r4 r0 1
+		asminst.Reg(AMOADD, R1, R2, R4, 0, 0), // 800c: .loop: amoadd r1 r2 r4
+		asminst.Imm(ADDI, R3, R3, dec),        // 8010: addi r3 r3 -1
+		asminst.Br(BNE, R3, R0, -3),           // 8014: bne r3 r0 .loop
+		asminst.Jmp(J, -1),                    // 8018: .end: j .end
+	}, 0x10000, 100)
+
+	// increments a counter with plain loads and stores, in a critical
+	// section guarded by a spin lock built with amoswap
+	testContention(t, []uint32{
+		asminst.Imm(LUI, R2, 0, 1),             // 8000: lui r2 1
+		asminst.Imm(ADDI, R3, R0, 100),         // 8004: addi r3 r0 100
+		asminst.Imm(ADDI, R4, R0, 1),           // 8008: .lock: addi r4 r0 1
+		asminst.Reg(AMOSWAP, R4, R2, R4, 0, 0), // 800c: amoswap r4 r2 r4
+		asminst.Br(BNE, R4, R0, -3),            // 8010: bne r4 r0 .lock
+		asminst.Imm(LW, R1, R2, 4),             // 8014: lw r1 r2 4
+		asminst.Imm(ADDI, R1, R1, 1),           // 8018: addi r1 r1 1
+		asminst.Imm(SW, R1, R2, 4),             // 801c: sw r1 r2 4
+		asminst.Reg(AMOSWAP, R4, R2, R0, 0, 0), // 8020: amoswap r4 r2 r0
+		asminst.Imm(ADDI, R3, R3, dec),         // 8024: addi r3 r3 -1
+		asminst.Br(BNE, R3, R0, -9),            // 8028: bne r3 r0 .lock
+		asminst.Jmp(J, -1),                     // 802c: .end: j .end
+	}, 0x10004, 100)
+}
@@ -0,0 +1,57 @@
+package arch
+
+// decoded is a predecoded instruction.
+type decoded struct {
+	in uint32
+	f  instFunc
+}
+
+// codePage has the predecoded instructions of a physical page. A
+// predecoded instruction is used only when the word in the page is
+// still the same, so writes to the page invalidate it.
+type codePage [PageSize / 4]decoded
+
+// instCache is the predecoded instruction cache of a core.
+type instCache struct {
+	pages map[*page]*codePage
+}
+
+func (c *instCache) codePage(p *page) *codePage {
+	if c.pages == nil {
+		c.pages = make(map[*page]*codePage)
+	}
+	ret, found := c.pages[p]
+	if !found {
+		ret = new(codePage)
+		c.pages[p] = ret
+	}
+	return ret
+}
+
+// fetch fetches the instruction at pc. It also returns the function
+// that executes the instruction when the core runs arch8 instructions.
+func (c *cpu) fetch(pc uint32) (uint32, instFunc, *Excep) {
+	ent, e := c.virtMem.fetchEntry(pc, c.ring)
+	if e != nil {
+		return 0, nil, e
+	}
+	if pc%4 != 0 {
+		return 0, nil, errMisalign
+	}
+
+	pos := (pc % PageSize) / 4
+	in := ent.p.uints[pos]
+	if c.arch8 == nil {
+		return in, nil, nil
+	}
+
+	if ent.code == nil {
+		ent.code = c.icache.codePage(ent.p)
+	}
+	d := &ent.code[pos]
+	if d.f == nil || d.in != in {
+		d.in = in
+		d.f = predecode(in)
+	}
+	return in, d.f, nil
+}
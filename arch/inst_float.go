@@ -0,0 +1,76 @@
+package arch
+
+import (
+	"math"
+)
+
+// NaN is the canonical quiet NaN. Float arithmetics that result in a
+// NaN always return this value, so that the results do not depend on
+// the host.
+const NaN uint32 = 0x7fc00000
+
+const floatSignBit = 0x80000000
+
+func canonFloat(f float32) uint32 {
+	if f != f {
+		return NaN
+	}
+	return math.Float32bits(f)
+}
+
+// floatToInt converts a float to a signed integer, truncating towards
+// zero. Out of range values saturate, and NaN converts to 0.
+func floatToInt(f float32) uint32 {
+	switch {
+	case f != f:
+		return 0
+	case f >= math.MaxInt32:
+		return math.MaxInt32
+	case f <= math.MinInt32:
+		return 1 << 31 // math.MinInt32
+	}
+	return uint32(int32(f))
+}
+
+func boolWord(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// floatOp executes a float instruction. Comparisons with NaN are always
+// false. FNEG and FABS only change the sign bit, even for NaN.
+func floatOp(funct, s1, s2 uint32) (uint32, bool) {
+	f1 := math.Float32frombits(s1)
+	f2 := math.Float32frombits(s2)
+
+	switch funct {
+	case FADD:
+		return canonFloat(f1 + f2), true
+	case FSUB:
+		return canonFloat(f1 - f2), true
+	case FMUL:
+		return canonFloat(f1 * f2), true
+	case FDIV:
+		return canonFloat(f1 / f2), true
+	case FINT:
+		return floatToInt(f1), true
+	case ITOF:
+		return math.Float32bits(float32(int32(s1))), true
+	case FEQ:
+		return boolWord(f1 == f2), true
+	case FLT:
+		return boolWord(f1 < f2), true
+	case FLE:
+		return boolWord(f1 <= f2), true
+	case FNEG:
+		return s1 ^ floatSignBit, true
+	case FABS:
+		return s1 &^ floatSignBit, true
+	case FSQRT:
+		// rounding the float64 square root is exact for float32.
+		return canonFloat(float32(math.Sqrt(float64(f1)))), true
+	}
+	return 0, false
+}
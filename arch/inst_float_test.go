@@ -0,0 +1,142 @@
+package arch
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	asminst "shanhu.io/smlvm/asm/inst"
+)
+
+var floatSpecials = []uint32{
+	0x00000000, // +0
+	0x80000000, // -0
+	0x3f800000, // 1
+	0xbf800000, // -1
+	0x3f000000, // 0.5
+	0xbfc00000, // -1.5
+	0x40490fdb, // pi
+	0x00000001, // smallest denormal
+	0x807fffff, // largest negative denormal
+	0x00800000, // smallest normal
+	0x7f7fffff, // max float
+	0xff7fffff, // -max float
+	0x4f000000, // 2^31
+	0xcf000000, // -2^31
+	0x4effffff, // largest float below 2^31
+	0x7f800000, // +inf
+	0xff800000, // -inf
+	0x7fc00000, // canonical NaN
+	0xffc00001, // negative NaN with payload
+	0x7f800001, // signaling NaN
+}
+
+func floatSamples() []uint32 {
+	r := rand.New(rand.NewSource(0))
+	ret := append([]uint32(nil), floatSpecials...)
+	for i := 0; i < 50; i++ {
+		ret = append(ret, r.Uint32())
+	}
+	return ret
+}
+
+// wantFloat applies the NaN rule of the arithmetic instructions to the
+// result computed by Go.
+func wantFloat(f float32) uint32 {
+	if math.IsNaN(float64(f)) {
+		return NaN
+	}
+	return math.Float32bits(f)
+}
+
+func wantBool(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func wantInt(f float32) uint32 {
+	switch {
+	case math.IsNaN(float64(f)):
+		return 0
+	case float64(f) >= 1<<31:
+		return 0x7fffffff
+	case float64(f) < -(1 << 31):
+		return 0x80000000
+	}
+	return uint32(int32(math.Trunc(float64(f))))
+}
+
+var floatTests = []struct {
+	funct uint32
+	want  func(a, b float32, x uint32) uint32
+}{
+	{FADD, func(a, b float32, _ uint32) uint32 { return wantFloat(a + b) }},
+	{FSUB, func(a, b float32, _ uint32) uint32 { return wantFloat(a - b) }},
+	{FMUL, func(a, b float32, _ uint32) uint32 { return wantFloat(a * b) }},
+	{FDIV, func(a, b float32, _ uint32) uint32 { return wantFloat(a / b) }},
+	{FEQ, func(a, b float32, _ uint32) uint32 { return wantBool(a == b) }},
+	{FLT, func(a, b float32, _ uint32) uint32 { return wantBool(a < b) }},
+	{FLE, func(a, b float32, _ uint32) uint32 { return wantBool(a <= b) }},
+	{FINT, func(a, _ float32, _ uint32) uint32 { return wantInt(a) }},
+	{ITOF, func(_, _ float32, x uint32) uint32 {
+		return math.Float32bits(float32(int32(x)))
+	}},
+	{FNEG, func(a, _ float32, x uint32) uint32 {
+		if math.IsNaN(float64(a)) {
+			return x ^ 0x80000000 // Go does not define the NaN sign
+		}
+		return math.Float32bits(-a)
+	}},
+	{FABS, func(a, _ float32, x uint32) uint32 {
+		if math.IsNaN(float64(a)) {
+			return x &^ 0x80000000
+		}
+		return math.Float32bits(float32(math.Abs(float64(a))))
+	}},
+	{FSQRT, func(a, _ float32, _ uint32) uint32 {
+		return wantFloat(float32(math.Sqrt(float64(a))))
+	}},
+}
+
+func TestInstFloat(t *testing.T) {
+	m := newPhyMemory(PageSize * 32)
+	cpu := newCPU(m, nil, new(instReg), 0)
+	samples := floatSamples()
+
+	for _, test := range floatTests {
+		in := asminst.Reg(test.funct, R3, R1, R2, 0, 1)
+		for _, x := range samples {
+			for _, y := range samples {
+				cpu.Reset()
+				m.WriteWord(InitPC, in)
+				cpu.regs[R1] = x
+				cpu.regs[R2] = y
+				if e := cpu.Tick(); e != nil {
+					t.Fatalf("funct %d: unexpected exception: %s",
+						test.funct, e)
+				}
+
+				a := math.Float32frombits(x)
+				b := math.Float32frombits(y)
+				want := test.want(a, b, x)
+				if got := cpu.regs[R3]; got != want {
+					t.Fatalf(
+						"funct %d on %08x, %08x: got %08x, want %08x",
+						test.funct, x, y, got, want,
+					)
+				}
+			}
+		}
+	}
+}
+
+func TestInstFloatInvalid(t *testing.T) {
+	m := newPhyMemory(PageSize * 32)
+	cpu := newCPU(m, nil, new(instReg), 0)
+	m.WriteWord(InitPC, asminst.Reg(FSQRT+1, R3, R1, R2, 0, 1))
+	if e := cpu.Tick(); e == nil || e.Code != ErrInvalidInst {
+		t.Errorf("want invalid instruction, got %v", e)
+	}
+}
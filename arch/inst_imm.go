@@ -18,6 +18,7 @@ func (i *instImm) I(cpu *cpu, in uint32) *Excep {
 	addr := s + ims
 	var e *Excep
 	var b byte
+	var h uint16
 
 	switch op {
 	case 0:
@@ -46,10 +47,18 @@ func (i *instImm) I(cpu *cpu, in uint32) *Excep {
 	case LBU:
 		b, e = cpu.readByte(addr)
 		d = uint32(b)
+	case LH:
+		h, e = cpu.readHalf(addr)
+		d = uint32(int32(int16(h)))
+	case LHU:
+		h, e = cpu.readHalf(addr)
+		d = uint32(h)
 	case SW:
 		e = cpu.writeWord(addr, d)
 	case SB:
 		e = cpu.writeByte(addr, byte(d))
+	case SH:
+		e = cpu.writeHalf(addr, uint16(d))
 	default:
 		return errInvalidInst
 	}
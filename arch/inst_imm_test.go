@@ -167,3 +167,63 @@ func TestInstImm(t *testing.T) {
 		}
 	}
 }
+
+func TestInstImmHalf(t *testing.T) {
+	m := newPhyMemory(PageSize * 32)
+	cpu := newCPU(m, nil, new(instImm), 0)
+
+	run := func(op, s, d, im uint32) *Excep {
+		in := (op & 0xff) << 24
+		in |= (s & 0x7) << 18
+		in |= (d & 0x7) << 21
+		in |= im & 0xffff
+		m.WriteWord(InitPC, in)
+		return cpu.Tick()
+	}
+
+	for i := 0; i < 100; i++ {
+		addr := uint32(PageSize*10) + uint32(rand.Intn(PageSize*4))
+		addr &^= 1
+		h := uint16(rand.Int63())
+		m.WriteByte(addr, byte(h))
+		m.WriteByte(addr+1, byte(h>>8))
+
+		cpu.Reset()
+		cpu.regs[R1] = addr
+		if e := run(LH, R1, R2, 0); e != nil {
+			t.Fatalf("lh: unexpected exception %s", e)
+		}
+		if got, want := cpu.regs[R2], uint32(int32(int16(h))); got != want {
+			t.Fatalf("lh: got 0x%08x, want 0x%08x", got, want)
+		}
+
+		cpu.Reset()
+		cpu.regs[R1] = addr
+		if e := run(LHU, R1, R2, 0); e != nil {
+			t.Fatalf("lhu: unexpected exception %s", e)
+		}
+		if got := cpu.regs[R2]; got != uint32(h) {
+			t.Fatalf("lhu: got 0x%08x, want 0x%04x", got, h)
+		}
+
+		cpu.Reset()
+		cpu.regs[R1] = addr
+		cpu.regs[R2] = 0xffff0000 | uint32(^h)
+		if e := run(SH, R1, R2, 0); e != nil {
+			t.Fatalf("sh: unexpected exception %s", e)
+		}
+		lo, _ := m.ReadByte(addr)
+		hi, _ := m.ReadByte(addr + 1)
+		if got := uint16(lo) | uint16(hi)<<8; got != ^h {
+			t.Fatalf("sh: got 0x%04x, want 0x%04x", got, ^h)
+		}
+	}
+
+	for _, op := range []uint32{LH, LHU, SH} {
+		cpu.Reset()
+		cpu.regs[R1] = PageSize*10 + 1
+		if e := run(op, R1, R2, 0); e == nil || e.Code != ErrMisalign {
+			t.Errorf("op %d: want misalign exception, got %v", op, e)
+		}
+	}
+}
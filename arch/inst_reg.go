@@ -1,11 +1,9 @@
 package arch
 
-import (
-	"math"
-)
-
 // InstReg executes register based instructions
-type instReg struct{}
+type instReg struct {
+	legacyDivZero bool // division by zero yields 0 instead of a fault
+}
 
 // I executes the register instruction.
 // Might return invalid instruction exception.
@@ -70,57 +68,44 @@ func (i *instReg) I(cpu *cpu, in uint32) *Excep {
 			d = uint32(int32(s1) * int32(s2))
 		case MULU:
 			d = s1 * s2
-		case DIV:
-			if s2 == 0 {
-				d = 0
-			} else {
-				d = uint32(int32(s1) / int32(s2))
-			}
-		case DIVU:
-			if s2 == 0 {
-				d = 0
-			} else {
-				d = s1 / s2
+		case MULH:
+			d = uint32(int64(int32(s1)) * int64(int32(s2)) >> 32)
+		case MULHU:
+			d = uint32(uint64(s1) * uint64(s2) >> 32)
+		case DIV, DIVU, MOD, MODU:
+			if s2 != 0 {
+				d = divOp(funct, s1, s2)
+			} else if !i.legacyDivZero {
+				return errDivZero
 			}
-		case MOD:
-			if s2 == 0 {
-				d = 0
-			} else {
-				d = uint32(int32(s1) % int32(s2))
-			}
-		case MODU:
-			if s2 == 0 {
-				d = 0
-			} else {
-				d = s1 % s2
+		case CAS, AMOADD, AMOSWAP:
+			var e *Excep
+			d, e = atomicOp(cpu, funct, cpu.regs[dest], s1, s2)
+			if e != nil {
+				return e
 			}
 		default:
 			return errInvalidInst
 		}
 	} else {
-		f1 := math.Float32frombits(s1)
-		f2 := math.Float32frombits(s2)
-		var fd float32
-		switch funct {
-		case FADD:
-			fd = f1 + f2
-		case FSUB:
-			fd = f1 - f2
-		case FMUL:
-			fd = f1 * f2
-		case FDIV:
-			fd = f1 / f2
-		case FINT:
-			d = uint32(f1)
-		default:
+		var ok bool
+		if d, ok = floatOp(funct, s1, s2); !ok {
 			return errInvalidInst
 		}
-
-		if funct != 4 {
-			d = math.Float32bits(fd)
-		}
 	}
 
 	cpu.regs[dest] = d
 	return nil
 }
+
+func divOp(funct, s1, s2 uint32) uint32 {
+	switch funct {
+	case DIV:
+		return uint32(int32(s1) / int32(s2))
+	case DIVU:
+		return s1 / s2
+	case MOD:
+		return uint32(int32(s1) % int32(s2))
+	}
+	return s1 % s2
+}
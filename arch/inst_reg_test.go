@@ -1,15 +1,19 @@
 package arch
 
 import (
+	"bytes"
 	"testing"
 
 	"math"
 	"math/rand"
+
+	asminst "shanhu.io/smlvm/asm/inst"
 )
 
 func TestInstReg(t *testing.T) {
 	m := newPhyMemory(PageSize * 32)
-	cpu := newCPU(m, nil, new(instReg), 0)
+	inst := new(instReg)
+	cpu := newCPU(m, nil, inst, 0)
 
 	tst := func(op, s1, s2, v1, v2, d, res uint32) {
 		cpu.Reset()
@@ -73,6 +77,9 @@ func TestInstReg(t *testing.T) {
 
 		got := cpu.regs[d]
 		fres := math.Float32bits(res)
+		if res != res {
+			fres = NaN
+		}
 		if got != fres {
 			t.Fatalf("got 0x%08x, expect 0x%08x", got, fres)
 		}
@@ -171,6 +178,12 @@ func TestInstReg(t *testing.T) {
 		return uint32(int32(a) * int32(b))
 	})
 	tf(MULU, func(a, b uint32) uint32 { return a * b })
+	tf(MULH, func(a, b uint32) uint32 {
+		return uint32(int64(int32(a)) * int64(int32(b)) >> 32)
+	})
+	tf(MULHU, func(a, b uint32) uint32 {
+		return uint32(uint64(a) * uint64(b) >> 32)
+	})
 
 	tf(DIV, func(a, b uint32) uint32 {
 		if b == 0 {
@@ -184,8 +197,10 @@ func TestInstReg(t *testing.T) {
 		}
 		return a / b
 	})
+	inst.legacyDivZero = true
 	tf0(DIV, func(a uint32) uint32 { return 0 })
 	tf0(DIVU, func(a uint32) uint32 { return 0 })
+	inst.legacyDivZero = false
 
 	tf(MOD, func(a, b uint32) uint32 {
 		if b == 0 {
@@ -199,11 +214,66 @@ func TestInstReg(t *testing.T) {
 		}
 		return a % b
 	})
+	inst.legacyDivZero = true
 	tf0(MOD, func(a uint32) uint32 { return 0 })
 	tf0(MODU, func(a uint32) uint32 { return 0 })
+	inst.legacyDivZero = false
 
 	tff(FADD, func(a, b float32) float32 { return a + b })
 	tff(FSUB, func(a, b float32) float32 { return a - b })
 	tff(FMUL, func(a, b float32) float32 { return a * b })
 	tff(FDIV, func(a, b float32) float32 { return a / b })
 }
+
+func TestInstRegDivZero(t *testing.T) {
+	m := newPhyMemory(PageSize * 32)
+	cpu := newCPU(m, nil, new(instReg), 0)
+
+	for _, op := range []uint32{DIV, DIVU, MOD, MODU} {
+		cpu.Reset()
+		m.WriteWord(InitPC, op|R3<<21|R1<<18|R2<<15)
+		cpu.regs[R1] = 7
+		cpu.regs[R3] = 5
+		e := cpu.Tick()
+		if e == nil || e.Code != ErrDivZero {
+			t.Errorf("op %d: want divide by zero, got %v", op, e)
+		}
+		if cpu.regs[PC] != InitPC {
+			t.Errorf("op %d: pc moved to %08x", op, cpu.regs[PC])
+		}
+		if cpu.regs[R3] != 5 {
+			t.Errorf("op %d: dest overwritten", op)
+		}
+	}
+}
+
+func TestLegacyDivZero(t *testing.T) {
+	insts := []uint32{
+		asminst.Imm(ADDI, R1, R0, 7),       // addi r1 r0 7
+		asminst.Reg(DIV, R3, R1, R2, 0, 0), // div r3 r1 r2
+		asminst.Sys(HALT, 0, 0),            // halt
+	}
+
+	m := newTestMachine(t, 1, insts)
+	if _, e := m.Run(10); e == nil || e.Code != ErrDivZero {
+		t.Errorf("want divide by zero, got %v", e)
+	}
+
+	c := &Config{Ncore: 1, LegacyDivZero: true}
+	m = newTestMachineConfig(t, c, insts)
+	if _, e := m.Run(1); e != nil {
+		t.Fatal(e)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := m.Snapshot(buf); err != nil {
+		t.Fatal(err)
+	}
+	m, err := RestoreMachine(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, e := m.Run(10); !IsHalt(e) {
+		t.Errorf("want halt after restore, got %v", e)
+	}
+}
@@ -15,6 +15,20 @@ func sysInfo(cpu *cpu, cmd uint32) (uint32, uint32) {
 	return 0, 0
 }
 
+// coreCtrl starts or stops a core. It returns 0 on success, and 1 when
+// the core index is out of range.
+func coreCtrl(cpu *cpu, cmd, index uint32) uint32 {
+	if cpu.cores == nil || index >= uint32(len(cpu.cores.cores)) {
+		return 1
+	}
+	if cmd == CORESTART {
+		cpu.cores.start(int(index))
+	} else {
+		cpu.cores.stop(int(index), int(cpu.index))
+	}
+	return 0
+}
+
 // I executes the system instruction.
 // Returns any exception encountered.
 func (i *instSys) I(cpu *cpu, in uint32) *Excep {
@@ -48,6 +62,9 @@ func (i *instSys) I(cpu *cpu, in uint32) *Excep {
 		if cpu.calls == nil {
 			return errInvalidInst
 		}
+		if cpu.yieldIO {
+			return errYield
+		}
 		return cpu.calls.invoke()
 	case IRET:
 		if cpu.UserMode() {
@@ -55,7 +72,26 @@ func (i *instSys) I(cpu *cpu, in uint32) *Excep {
 		}
 		return cpu.Iret()
 	case SYSINFO:
-		v1, v2 = sysInfo(cpu, v1)
+		switch v1 {
+		case CORESTART, CORESTOP:
+			if cpu.UserMode() {
+				return errInvalidInst
+			}
+			if cpu.yieldIO {
+				return errYield // touches other cores
+			}
+			v1 = coreCtrl(cpu, v1, v2)
+		default:
+			v1, v2 = sysInfo(cpu, v1)
+		}
+	case IPI:
+		if cpu.UserMode() {
+			return errInvalidInst
+		}
+		if cpu.yieldIO {
+			return errYield // touches other cores
+		}
+		v1 = ipi(cpu, v1, byte(v2))
 	case SLEEP:
 		// TODO(h8liu): remove this sleep.
 		if !cpu.sleeping {
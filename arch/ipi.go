@@ -0,0 +1,33 @@
+package arch
+
+// IPIAll is the target of an IPI instruction that interrupts all the
+// cores, including the sender.
+const IPIAll = 0xffffffff
+
+// ipi issues an inter-processor interrupt of code on the target core,
+// and wakes the core up if it is halted. Code 0 only wakes the core up.
+// It returns 0 on success, and 1 when the target is out of range.
+func ipi(cpu *cpu, target uint32, code byte) uint32 {
+	mc := cpu.cores
+	if mc == nil {
+		return 1
+	}
+	if target == IPIAll {
+		for i := range mc.cores {
+			mc.ipi(i, code)
+		}
+		return 0
+	}
+	if target >= uint32(len(mc.cores)) {
+		return 1
+	}
+	mc.ipi(int(target), code)
+	return 0
+}
+
+func (c *multiCore) ipi(i int, code byte) {
+	if code != 0 {
+		c.cores[i].Interrupt(code)
+	}
+	c.start(i)
+}
@@ -0,0 +1,86 @@
+package arch
+
+import (
+	"testing"
+
+	asminst "shanhu.io/smlvm/asm/inst"
+)
+
+func intPendingBit(c *cpu, code byte) bool {
+	b := c.interrupt.readByte(intPending + uint32(code/8))
+	return b&(0x1<<(code%8)) != 0
+}
+
+func TestIPI(t *testing.T) {
+	insts := []uint32{
+		asminst.Imm(ADDI, R1, R0, CPUID), // 8000: addi r1 r0 CPUID
+		asminst.Sys(SYSINFO, R1, R2),     // 8004: sysinfo r1 r2
+		asminst.Br(BNE, R1, R0, 5),       // 8008: bne r1 r0 .second
+		asminst.Imm(ADDI, R1, R0, 1),     // 800c: addi r1 r0 1
+		asminst.Imm(ADDI, R2, R0, 20),    // 8010: addi r2 r0 20
+		asminst.Sys(IPI, R1, R2),         // 8014: ipi r1 r2
+		asminst.Sys(HALT, 0, 0),          // 8018: halt
+		asminst.Sys(HALT, 0, 0),          // 801c: halt
+		asminst.Sys(HALT, 0, 0),          // 8020: .second: halt
+		asminst.Imm(LUI, R2, 0, 1),       // 8024: lui r2 1
+		asminst.Imm(ADDI, R3, R0, 7),     // 8028: addi r3 r0 7
+		asminst.Imm(SW, R3, R2, 0),       // 802c: sw r3 r2
+		asminst.Sys(HALT, 0, 0),          // 8030: halt
+	}
+
+	for _, parallel := range []bool{false, true} {
+		m := newTestMachineConfig(t, &Config{
+			Ncore:    2,
+			Parallel: parallel,
+			Quantum:  2,
+		}, insts)
+		if _, e := m.Run(1000); e == nil || e.Core != 1 || !IsHalt(e) {
+			t.Fatalf("parallel=%t: want halt on core 1, got %v",
+				parallel, e)
+		}
+		if r1 := m.DumpRegs(0)[R1]; r1 != 0 {
+			t.Errorf("parallel=%t: ipi returned %d", parallel, r1)
+		}
+		if got, _ := m.phyMem.ReadWord(0x10000); got != 7 {
+			t.Errorf("parallel=%t: core 1 did not wake up", parallel)
+		}
+		if !intPendingBit(m.cores.cores[1], 20) {
+			t.Errorf("parallel=%t: interrupt not issued", parallel)
+		}
+		if intPendingBit(m.cores.cores[0], 20) {
+			t.Errorf("parallel=%t: interrupt issued on the sender", parallel)
+		}
+	}
+}
+
+func TestIPITargets(t *testing.T) {
+	insts := []uint32{
+		asminst.Imm(ADDI, R1, R0, 0xffff), // 8000: addi r1 r0 -1
+		asminst.Imm(ADDI, R2, R0, 21),     // 8004: addi r2 r0 21
+		asminst.Sys(IPI, R1, R2),          // 8008: ipi r1 r2
+		asminst.Imm(ADDI, R3, R1, 0),      // 800c: addi r3 r1 0
+		asminst.Imm(ADDI, R1, R0, 2),      // 8010: addi r1 r0 2
+		asminst.Sys(IPI, R1, R2),          // 8014: ipi r1 r2
+		asminst.Sys(HALT, 0, 0),           // 8018: halt
+	}
+	m := newTestMachine(t, 1, insts)
+	if _, e := m.Run(100); !IsHalt(e) {
+		t.Fatalf("want halt, got %v", e)
+	}
+	regs := m.DumpRegs(0)
+	if regs[R3] != 0 {
+		t.Errorf("ipi to all returned %d", regs[R3])
+	}
+	if regs[R1] != 1 {
+		t.Errorf("ipi to an invalid core returned %d", regs[R1])
+	}
+	if !intPendingBit(m.cores.cores[0], 21) {
+		t.Error("interrupt not issued to all cores")
+	}
+
+	m = newTestMachine(t, 1, insts)
+	m.cores.cores[0].ring = 1
+	if _, e := m.Run(100); e == nil || e.Code != ErrInvalidInst {
+		t.Errorf("want invalid instruction in user mode, got %v", e)
+	}
+}
@@ -0,0 +1,56 @@
+package arch
+
+// memUndo saves the old value of a memory word before a write.
+type memUndo struct {
+	p    *page
+	pos  uint32 // word position in the page
+	old  uint32
+	core int // the core that writes, -1 for devices
+}
+
+// journal records the old values of memory writes, so that the writes
+// can be undone.
+type journal struct {
+	entries []memUndo
+	core    int
+}
+
+func (j *journal) record(p *page, pos uint32) {
+	j.entries = append(j.entries, memUndo{
+		p:    p,
+		pos:  pos,
+		old:  p.uints[pos],
+		core: j.core,
+	})
+}
+
+// take returns the recorded entries and clears the journal.
+func (j *journal) take() []memUndo {
+	ret := j.entries
+	j.entries = nil
+	return ret
+}
+
+// setJournal sets the journal for all the pages. j can be nil to stop
+// journaling.
+func (pm *phyMemory) setJournal(j *journal) {
+	pm.journal = j
+	for _, p := range pm.pages {
+		p.journal = j
+	}
+}
+
+// journalCore sets the core that is writing the memory.
+func (pm *phyMemory) journalCore(core int) {
+	if pm.journal != nil {
+		pm.journal.core = core
+	}
+}
+
+// undoMem undoes the memory writes in reverse order.
+func undoMem(entries []memUndo) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		e.p.set(e.pos, e.old)
+	}
+}
@@ -0,0 +1,126 @@
+// Package keyboard provides a keyboard device that sends key events to
+// the guest as messages.
+//
+// A message starts with the event kind. A key event is 4 bytes long:
+// the kind, the modifiers, and the uint16 key code in little endian. A
+// text event is the kind followed by the text in UTF-8.
+package keyboard
+
+import (
+	"unicode/utf8"
+
+	"shanhu.io/smlvm/arch/vpc"
+)
+
+// Event kinds.
+const (
+	KeyDown = 1
+	KeyUp   = 2
+	Text    = 3
+)
+
+// Modifiers of key events, as bits.
+const (
+	ModShift = 1 << iota
+	ModCtrl
+	ModAlt
+	ModMeta
+)
+
+// Key codes. Keys of printable characters and the control keys below
+// use their ASCII codes, where letter keys use the lower case letters.
+const (
+	CodeBackspace = 8
+	CodeTab       = 9
+	CodeEnter     = 13
+	CodeEscape    = 27
+	CodeSpace     = 32
+	CodeDelete    = 127
+)
+
+// Key codes of the keys without ASCII codes.
+const (
+	CodeUp = 0x100 + iota
+	CodeDown
+	CodeLeft
+	CodeRight
+	CodeHome
+	CodeEnd
+	CodePageUp
+	CodePageDown
+	CodeInsert
+	CodeF1 // F2-F12 follow F1
+)
+
+// Modes of the keyboard, as bits. The guest sets the mode with a
+// request of two bytes: cmdMode and the mode.
+const (
+	ModeKeys = 1 // sends key down and key up events
+	ModeText = 2 // sends text events
+
+	modeAll = ModeKeys | ModeText
+)
+
+const cmdMode = 0
+
+// MaxText is the maximum length of the text in a text event.
+const MaxText = vpc.MaxLen - 1
+
+// Keyboard is a keyboard device.
+type Keyboard struct {
+	send vpc.Sender
+	mode byte
+}
+
+// New creates a keyboard that sends the events with s. It sends key
+// events by default.
+func New(s vpc.Sender) *Keyboard {
+	return &Keyboard{send: s, mode: ModeKeys}
+}
+
+// Mode returns the mode of the keyboard.
+func (k *Keyboard) Mode() byte { return k.mode }
+
+// SetMode sets the mode of the keyboard.
+func (k *Keyboard) SetMode(mode byte) { k.mode = mode & modeAll }
+
+// Handle handles a request that sets the mode. It returns the old mode.
+func (k *Keyboard) Handle(req []byte) ([]byte, int32) {
+	if len(req) != 2 || req[0] != cmdMode || req[1]&^modeAll != 0 {
+		return nil, vpc.ErrInvalidArg
+	}
+	old := k.mode
+	k.mode = req[1]
+	return []byte{old}, 0
+}
+
+// Press sends a key down event, or a key up event if down is false.
+func (k *Keyboard) Press(code uint16, mods byte, down bool) {
+	if k.mode&ModeKeys == 0 {
+		return
+	}
+	kind := byte(KeyUp)
+	if down {
+		kind = KeyDown
+	}
+	k.send.Send([]byte{kind, mods, byte(code), byte(code >> 8)})
+}
+
+// Type sends text events of s, splitting it into events of at most
+// MaxText bytes without breaking the characters.
+func (k *Keyboard) Type(s string) {
+	if k.mode&ModeText == 0 {
+		return
+	}
+	for len(s) > 0 {
+		n := len(s)
+		if n > MaxText {
+			n = MaxText
+			for n > 0 && !utf8.RuneStart(s[n]) {
+				n--
+			}
+		}
+		k.send.Send(append([]byte{Text}, s[:n]...))
+		s = s[n:]
+	}
+}
@@ -0,0 +1,58 @@
+package keyboard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"shanhu.io/smlvm/arch/vpc"
+)
+
+type testSender struct{ msgs [][]byte }
+
+func (s *testSender) Send(p []byte) { s.msgs = append(s.msgs, p) }
+
+func TestKeyboard(t *testing.T) {
+	s := new(testSender)
+	k := New(s)
+
+	k.Press(CodeUp, ModShift|ModCtrl, true)
+	k.Type("ignored")
+	k.Press('a', 0, false)
+	want := [][]byte{
+		{KeyDown, ModShift | ModCtrl, 0x00, 0x01},
+		{KeyUp, 0, 'a', 0},
+	}
+	if len(s.msgs) != len(want) {
+		t.Fatalf("got messages %v, want %v", s.msgs, want)
+	}
+	for i, m := range want {
+		if !bytes.Equal(s.msgs[i], m) {
+			t.Errorf("message %d: got %v, want %v", i, s.msgs[i], m)
+		}
+	}
+
+	resp, code := k.Handle([]byte{cmdMode, ModeText})
+	if code != 0 || !bytes.Equal(resp, []byte{ModeKeys}) {
+		t.Fatalf("set mode got %v, code %d", resp, code)
+	}
+	s.msgs = nil
+	k.Press('a', 0, true)
+	text := strings.Repeat("x", MaxText-1) + "中"
+	k.Type(text)
+	if len(s.msgs) != 2 {
+		t.Fatalf("got %d messages, want 2", len(s.msgs))
+	}
+	if got := string(s.msgs[0][1:]) + string(s.msgs[1][1:]); got != text {
+		t.Errorf("text not split correctly")
+	}
+	if s.msgs[1][0] != Text || string(s.msgs[1][1:]) != "中" {
+		t.Errorf("got last message %v", s.msgs[1])
+	}
+
+	for _, req := range [][]byte{nil, {cmdMode}, {1, 0}, {cmdMode, 4}} {
+		if _, code := k.Handle(req); code != vpc.ErrInvalidArg {
+			t.Errorf("request %v got code %d", req, code)
+		}
+	}
+}
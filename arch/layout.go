@@ -18,6 +18,7 @@ const (
 	bootArgBase = 0x8   // 8-c
 	clicksBase  = 0x10  // 10-14
 	romBase     = 0x100 // 100-180
+	diskBase    = 0x200 // 200-214
 )
 
 const (
@@ -26,4 +27,8 @@ const (
 	serviceRand
 	serviceClock
 	serviceTable
+	serviceLink
+	serviceTimer
+	serviceDisplay
+	serviceKeyboard
 )
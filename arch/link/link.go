@@ -0,0 +1,147 @@
+// Package link provides a virtual packet link that connects the VPC
+// services of several machines.
+package link
+
+import (
+	"encoding/binary"
+	"math/rand"
+
+	"shanhu.io/smlvm/arch/vpc"
+	"shanhu.io/smlvm/coder"
+)
+
+// Commands of a link request.
+const (
+	cmdAddr = 0 // returns the address of the endpoint
+	cmdSend = 1 // sends a packet to a peer
+)
+
+// HeaderLen is the length of the source address in front of every
+// delivered packet.
+const HeaderLen = 4
+
+// MaxPayload is the maximum payload length of a packet.
+const MaxPayload = vpc.MaxLen - HeaderLen
+
+// Config contains the properties of a link network.
+type Config struct {
+	Latency  int     // ticks that a packet takes to arrive
+	DropRate float64 // probability that a packet is dropped
+	Seed     int64   // random seed for dropping packets
+}
+
+type packet struct {
+	arrive int64
+	dest   *Endpoint
+	p      []byte
+}
+
+// Network delivers packets between endpoints. It runs on ticks, so
+// the delivery is deterministic when the endpoints are ticked in a
+// fixed order.
+type Network struct {
+	latency  int
+	dropRate float64
+	rand     *rand.Rand
+
+	now     int64
+	ends    []*Endpoint
+	pending []*packet // in the order of arrival
+}
+
+// NewNetwork creates a new link network.
+func NewNetwork(c *Config) *Network {
+	return &Network{
+		latency:  c.Latency,
+		dropRate: c.DropRate,
+		rand:     rand.New(rand.NewSource(c.Seed)),
+	}
+}
+
+// Endpoint creates a new endpoint on the network. Incoming packets are
+// sent to s.
+func (n *Network) Endpoint(s vpc.Sender) *Endpoint {
+	ret := &Endpoint{
+		net:  n,
+		addr: uint32(len(n.ends)),
+		in:   s,
+	}
+	n.ends = append(n.ends, ret)
+	return ret
+}
+
+func (n *Network) send(from *Endpoint, to uint32, p []byte) int32 {
+	if int(to) >= len(n.ends) {
+		return vpc.ErrNotFound
+	}
+	if n.dropRate > 0 && n.rand.Float64() < n.dropRate {
+		return 0 // silently dropped
+	}
+
+	bs := make([]byte, HeaderLen+len(p))
+	binary.LittleEndian.PutUint32(bs, from.addr)
+	copy(bs[HeaderLen:], p)
+	n.pending = append(n.pending, &packet{
+		arrive: n.now + int64(n.latency),
+		dest:   n.ends[to],
+		p:      bs,
+	})
+	return 0
+}
+
+// Tick advances the network by one tick, and delivers the packets
+// that arrive.
+func (n *Network) Tick() {
+	n.now++
+	i := 0
+	for ; i < len(n.pending); i++ {
+		p := n.pending[i]
+		if p.arrive > n.now {
+			break
+		}
+		p.dest.in.Send(p.p)
+	}
+	n.pending = n.pending[i:]
+}
+
+// Pending returns the number of packets that are not delivered yet.
+func (n *Network) Pending() int { return len(n.pending) }
+
+// Endpoint is a VPC service that sends packets on a network.
+type Endpoint struct {
+	net  *Network
+	addr uint32
+	in   vpc.Sender
+}
+
+// Addr returns the address of the endpoint.
+func (e *Endpoint) Addr() uint32 { return e.addr }
+
+// Handle handles an incoming VPC. A request is either a single cmdAddr
+// byte, which returns the address of the endpoint as a uint32, or a
+// cmdSend byte, followed by the uint32 destination address and the
+// payload. A packet is delivered to the peer with the source address
+// in front of the payload.
+func (e *Endpoint) Handle(req []byte) ([]byte, int32) {
+	dec := coder.NewDecoder(req)
+	switch dec.U8() {
+	case cmdAddr:
+		if dec.Err != nil || len(req) != 1 {
+			return nil, vpc.ErrInvalidArg
+		}
+		ret := make([]byte, 4)
+		binary.LittleEndian.PutUint32(ret, e.addr)
+		return ret, 0
+	case cmdSend:
+		to := dec.U32()
+		if dec.Err != nil {
+			return nil, vpc.ErrInvalidArg
+		}
+		p := req[5:]
+		if len(p) > MaxPayload {
+			return nil, vpc.ErrInvalidArg
+		}
+		return nil, e.net.send(e, to, p)
+	}
+	return nil, vpc.ErrInvalidArg
+}
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"math/rand"
 	"time"
 
+	"shanhu.io/smlvm/arch/display"
+	"shanhu.io/smlvm/arch/keyboard"
 	"shanhu.io/smlvm/arch/misc"
 	"shanhu.io/smlvm/arch/screen"
+	"shanhu.io/smlvm/arch/session"
 	"shanhu.io/smlvm/arch/table"
 	"shanhu.io/smlvm/image"
 )
@@ -22,13 +24,23 @@ type Machine struct {
 	devices []device
 	console *console
 	clicks  *screen.Clicks
+	keys    *keyboard.Keyboard
 	screen  *screen.Screen
 	table   *table.Table
+	display *display.Display
 	rand    *misc.Rand
 	ticker  *ticker
+	timer   *timer
 	rom     *rom
+	disk    *disk
+	inputs  *inputLog // records or replays the inputs
+	session *session.Recorder
 
-	cores *multiCore
+	cores    *multiCore
+	profiler *Profiler
+	quantum  int // ticks per quantum when running in parallel
+
+	legacyDivZero bool
 
 	// Sections that are loaded into the machine
 	Sections []*image.Section
@@ -54,8 +66,20 @@ func NewMachine(c *Config) *Machine {
 		c.Ncore = 1
 	}
 	m := new(Machine)
+	if c.Replay != nil {
+		c.RandSeed = c.Replay.seed
+		m.inputs = newReplayer(c.Replay)
+	} else if c.Record != nil {
+		if c.RandSeed == 0 {
+			c.RandSeed = time.Now().UnixNano()
+		}
+		m.inputs = newRecorder(c.Record, c.RandSeed)
+	}
 	m.phyMem = newPhyMemory(c.MemSize)
-	m.inst = new(instArch8)
+	a8 := new(instArch8)
+	a8.reg.legacyDivZero = c.LegacyDivZero
+	m.inst = a8
+	m.legacyDivZero = c.LegacyDivZero
 	m.calls = newCalls(m.phyMem.Page(pageRPC), m.phyMem)
 	m.cores = newMultiCore(c.Ncore, m.phyMem, m.calls, m.inst)
 
@@ -63,27 +87,56 @@ func NewMachine(c *Config) *Machine {
 	p := m.phyMem.Page(pageBasicIO)
 
 	m.console = newConsole(p, m.cores)
+	m.console.inputs = m.inputs
 	m.ticker = newTicker(m.cores)
 
-	m.calls.register(serviceConsole, m.console)
-	m.calls.register(serviceRand, makeRand(c))
-	m.calls.register(serviceClock, &misc.Clock{PerfNow: c.PerfNow})
+	m.calls.register(serviceConsole, "console", m.console)
+	m.rand = makeRand(c)
+	m.calls.register(serviceRand, "rand", m.rand)
+	m.calls.register(serviceClock, "clock", &misc.Clock{
+		Now:     m.inputs.wrapClock(time.Now),
+		PerfNow: m.inputs.wrapPerfClock(c.PerfNow),
+	})
+
+	tickTime := c.TickTime
+	if tickTime <= 0 {
+		tickTime = DefaultTickTime
+	}
+	m.timer = newTimer(m.cores, m.calls.sender(serviceTimer), tickTime)
+	m.calls.register(serviceTimer, "timer", m.timer)
 
 	m.addDevice(m.ticker)
 	m.addDevice(m.console)
+	m.addDevice(m.timer)
+
+	screenRender, tableRender := c.Screen, c.Table
+	if c.Session != nil {
+		m.session = session.NewRecorder(
+			c.Session, c.Screen, c.Table, m.timer.time,
+		)
+		screenRender, tableRender = m.session, m.session
+	}
 
-	if c.Screen != nil {
+	if screenRender != nil {
 		m.clicks = screen.NewClicks(m.calls.sender(serviceScreen))
-		s := screen.New(c.Screen)
+		s := screen.New(screenRender)
 		m.screen = s
 		m.addDevice(s)
-		m.calls.register(serviceScreen, s)
+		m.calls.register(serviceScreen, "screen", s)
+
+		m.keys = keyboard.New(m.calls.sender(serviceKeyboard))
+		m.calls.register(serviceKeyboard, "keyboard", m.keys)
 	}
 
-	if c.Table != nil {
-		t := table.New(c.Table, m.calls.sender(serviceTable))
+	if tableRender != nil {
+		t := table.New(tableRender, m.calls.sender(serviceTable))
 		m.table = t
-		m.calls.register(serviceTable, t) // hook vpc all
+		m.calls.register(serviceTable, "table", t) // hook vpc all
+	}
+
+	if c.Display != nil {
+		m.display = display.New(m.phyMem, c.Display)
+		m.calls.register(serviceDisplay, "display", m.display)
 	}
 
 	sys := m.phyMem.Page(pageSysInfo)
@@ -99,12 +152,29 @@ func NewMachine(c *Config) *Machine {
 	if c.Output != nil {
 		m.console.Output = c.Output
 	}
+	if c.Input != nil {
+		m.console.setInput(c.Input)
+	}
 	if c.ROM != "" {
 		m.mountROM(c.ROM)
 	}
+	if c.Disk != "" {
+		m.mountDisk(c.Disk)
+	}
 	if c.RandSeed != 0 {
 		m.randSeed(c.RandSeed)
 	}
+	if c.Tracer != nil {
+		m.SetTracer(c.Tracer)
+	}
+	m.profiler = c.Profiler
+	if c.Parallel {
+		m.quantum = c.Quantum
+		if m.quantum <= 0 {
+			m.quantum = DefaultQuantum
+		}
+		m.phyMem.shared = true
+	}
 	m.phyMem.WriteWord(AddrBootArg, c.BootArg) // ignoring write error
 
 	return m
@@ -113,9 +183,18 @@ func NewMachine(c *Config) *Machine {
 func (m *Machine) mountROM(root string) {
 	p := m.phyMem.Page(pageBasicIO)
 	m.rom = newROM(p, m.phyMem, m.cores, root)
+	m.rom.inputs = m.inputs
 	m.addDevice(m.rom)
 }
 
+func (m *Machine) mountDisk(path string) {
+	p := m.phyMem.Page(pageBasicIO)
+	m.disk = newDisk(p, m.phyMem, m.cores, path)
+	m.disk.inputs = m.inputs
+	m.disk.updateSize()
+	m.addDevice(m.disk)
+}
+
 // ReadWord reads a word from the virtual address space.
 func (m *Machine) ReadWord(core byte, virtAddr uint32) (uint32, error) {
 	return m.cores.readWord(core, virtAddr)
@@ -128,17 +207,32 @@ func (m *Machine) DumpRegs(core byte) []uint32 {
 
 func (m *Machine) addDevice(d device) { m.devices = append(m.devices, d) }
 
-// Tick proceeds the simulation by one tick.
-func (m *Machine) Tick() *CoreExcep {
+func (m *Machine) tickDevices() {
+	m.deliverClicks()
+	m.inputs.tick()
 	for _, d := range m.devices {
 		d.Tick()
 	}
-	return m.cores.Tick()
 }
 
-// Run simulates nticks. It returns the number of ticks
-// simulated without error, and the first met error if any.
+// Tick proceeds the simulation by one tick.
+func (m *Machine) Tick() *CoreExcep {
+	m.tickDevices()
+	e := m.cores.Tick()
+	if m.profiler != nil {
+		m.profiler.tick(m)
+	}
+	return e
+}
+
+// Run simulates nticks. It returns the number of ticks simulated, and
+// the exception that stops the machine if any, which is a halt when all
+// cores are halted, or the first other exception met on any core.
 func (m *Machine) Run(nticks int) (int, *CoreExcep) {
+	if m.parallel() {
+		return m.runParallel(nticks)
+	}
+
 	n := 0
 	for i := 0; nticks == 0 || i < nticks; i++ {
 		e := m.Tick()
@@ -178,7 +272,7 @@ func (m *Machine) WriteBytes(r io.Reader, offset uint32) error {
 }
 
 func (m *Machine) randSeed(s int64) {
-	m.ticker.Rand = rand.New(rand.NewSource(s))
+	m.ticker.seed(s, 0)
 }
 
 // LoadSections loads a list of sections into the machine.
@@ -233,6 +327,10 @@ func (m *Machine) LoadImageBytes(bs []byte) error {
 // PrintCoreStatus prints the cpu statuses.
 func (m *Machine) PrintCoreStatus() { m.cores.PrintStatus() }
 
+// SetInput sets the console input of the machine. The input is read
+// on a separate goroutine.
+func (m *Machine) SetInput(r io.Reader) { m.console.setInput(r) }
+
 // FlushScreen flushes updates in the frame buffer to the
 // screen device, even if the device has not asked for an update.
 func (m *Machine) FlushScreen() {
@@ -243,20 +341,68 @@ func (m *Machine) FlushScreen() {
 
 // Click sends in a mouse click at the particular location.
 func (m *Machine) Click(line, col uint8) {
-	if m.clicks == nil {
+	if m.clicks == nil || m.inputs.replaying() {
 		return
 	}
+	m.inputs.record(inputClick, []byte{line, col})
+	m.click(line, col)
+}
+
+func (m *Machine) click(line, col uint8) {
+	if m.session != nil {
+		m.session.Click(line, col)
+	}
 	m.clicks.Click(line, col)
 }
 
+// KeyPress sends in a key down event, or a key up event if down is
+// false. The key codes and the modifiers are defined in the keyboard
+// package.
+func (m *Machine) KeyPress(code uint16, mods uint8, down bool) {
+	if m.keys == nil || m.inputs.replaying() {
+		return
+	}
+	bs := []byte{byte(code), byte(code >> 8), mods, 0}
+	if down {
+		bs[3] = 1
+	}
+	m.inputs.record(inputKey, bs)
+	m.keys.Press(code, mods, down)
+}
+
+// TypeText sends in text input from the keyboard.
+func (m *Machine) TypeText(s string) {
+	if m.keys == nil || m.inputs.replaying() {
+		return
+	}
+	m.inputs.record(inputText, []byte(s))
+	m.keys.Type(s)
+}
+
 // ClickTable sends a click on the table at the particular location.
 func (m *Machine) ClickTable(pos uint8) {
-	if m.table == nil {
+	if m.table == nil || m.inputs.replaying() {
 		return
 	}
+	m.inputs.record(inputTableClick, []byte{pos})
+	m.clickTable(pos)
+}
+
+func (m *Machine) clickTable(pos uint8) {
+	if m.session != nil {
+		m.session.ClickTable(pos)
+	}
 	m.table.Click(pos)
 }
 
+// SessionErr returns the error of recording the session if any.
+func (m *Machine) SessionErr() error {
+	if m.session == nil {
+		return nil
+	}
+	return m.session.Err()
+}
+
 // SleepTime returns the sleeping time required before next execution.
 func (m *Machine) SleepTime() (time.Duration, bool) {
 	return m.calls.sleepTime()
@@ -0,0 +1,52 @@
+package arch
+
+import (
+	"testing"
+
+	asminst "shanhu.io/smlvm/asm/inst"
+)
+
+var benchInsts = []uint32{
+	asminst.Imm(LUI, R2, 0, 1),   // 8000: lui r2 1
+	asminst.Imm(LW, R1, R2, 0),   // 8004: .loop: lw r1 r2
+	asminst.Imm(ADDI, R1, R1, 1), // 8008: addi r1 r1 1
+	asminst.Imm(SW, R1, R2, 0),   // 800c: sw r1 r2
+	asminst.Jmp(J, -4),           // 8010: j .loop
+}
+
+func benchRun(b *testing.B, m *Machine) {
+	b.ResetTimer()
+	if _, e := m.Run(b.N); e != nil {
+		b.Fatal(e)
+	}
+}
+
+func BenchmarkRun(b *testing.B) {
+	m := NewMachine(&Config{InitPC: InitPC})
+	for i, in := range benchInsts {
+		m.phyMem.WriteWord(InitPC+uint32(i)*4, in)
+	}
+	benchRun(b, m)
+}
+
+// identityMap maps the first npage pages to themselves with a page
+// table at root.
+func identityMap(m *Machine, root uint32, npage uint32) {
+	const valid = 1 << pteValid
+	sub := root + PageSize
+	m.phyMem.WriteWord(root, sub|valid)
+	for i := uint32(0); i < npage; i++ {
+		m.phyMem.WriteWord(sub+i*4, i*PageSize|valid)
+	}
+}
+
+func BenchmarkRunPaged(b *testing.B) {
+	m := NewMachine(&Config{InitPC: InitPC})
+	for i, in := range benchInsts {
+		m.phyMem.WriteWord(InitPC+uint32(i)*4, in)
+	}
+	const root = 0x100000
+	identityMap(m, root, 64)
+	m.cores.cores[0].virtMem.SetTable(root)
+	benchRun(b, m)
+}
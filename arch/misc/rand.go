@@ -3,6 +3,7 @@ package misc
 import (
 	crand "crypto/rand"
 	"io"
+	"io/ioutil"
 	"log"
 	"math/rand"
 	"time"
@@ -12,15 +13,33 @@ import (
 
 // Rand provides a random number generator.
 type Rand struct {
-	r io.Reader
+	r    io.Reader
+	seed int64
+	n    uint64 // number of bytes read
 }
 
 // NewRand creates a new random number generator.
 func NewRand(seed int64) *Rand {
 	r := rand.New(rand.NewSource(seed))
-	return &Rand{r: r}
+	return &Rand{r: r, seed: seed}
 }
 
+// NewRandAt creates a new random number generator that continues the
+// random stream of seed after the first n bytes, i.e. a generator with
+// the state returned by State.
+func NewRandAt(seed int64, n uint64) *Rand {
+	ret := NewRand(seed)
+	if _, err := io.CopyN(ioutil.Discard, ret.r, int64(n)); err != nil {
+		panic(err) // the seeded stream never fails
+	}
+	ret.n = n
+	return ret
+}
+
+// State returns the seed and the number of bytes read so far. It is
+// meaningless for generators created with NewCryptoRand.
+func (r *Rand) State() (seed int64, n uint64) { return r.seed, r.n }
+
 // NewTimeRand creates a new random number generator with the current time as
 // the seed.
 func NewTimeRand() *Rand {
@@ -37,7 +56,8 @@ func NewCryptoRand() *Rand {
 // Handle handles incoming request to generate a random number.
 func (r *Rand) Handle(_ []byte) ([]byte, int32) {
 	ret := make([]byte, 4)
-	_, err := r.r.Read(ret)
+	n, err := r.r.Read(ret)
+	r.n += uint64(n)
 	if err != nil {
 		log.Println(err)
 		return nil, vpc.ErrInternal
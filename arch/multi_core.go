@@ -8,6 +8,9 @@ import (
 type multiCore struct {
 	cores  []*cpu
 	phyMem *phyMemory
+
+	lastHalt int // the core that halted last, -1 for none
+	lastStop int // the core that issued the last CORESTOP, -1 for none
 }
 
 // NewMultiCore creates a shared memory multicore processor.
@@ -19,9 +22,12 @@ func newMultiCore(n int, mem *phyMemory, c *calls, i inst) *multiCore {
 	ret := new(multiCore)
 	ret.cores = make([]*cpu, n)
 	ret.phyMem = mem
+	ret.lastHalt = -1
+	ret.lastStop = -1
 
 	for ind := range ret.cores {
 		ret.cores[ind] = newCPU(mem, c, i, byte(ind))
+		ret.cores[ind].cores = ret
 	}
 
 	return ret
@@ -33,16 +39,61 @@ type CoreExcep struct {
 	*Excep
 }
 
-// Tick performs one tick on each core.
+// Tick performs one tick on each running core. It returns the halt
+// exception of the last halted core when all cores are halted, or the
+// first other exception met.
 func (c *multiCore) Tick() *CoreExcep {
 	for i, core := range c.cores {
+		if core.halted {
+			continue
+		}
+		c.phyMem.journalCore(i)
 		e := core.Tick()
-		if e != nil {
+		if e != nil && e.Code == ErrHalt {
+			c.halt(i)
+		} else if e != nil {
+			c.phyMem.journalCore(-1)
 			return &CoreExcep{i, e}
 		}
 	}
+	c.phyMem.journalCore(-1)
+
+	return c.allHalted()
+}
+
+// halt halts a core that just executed HALT.
+func (c *multiCore) halt(i int) {
+	c.cores[i].halt()
+	c.lastHalt = i
+}
 
-	return nil
+// stop stops core i on CORESTOP issued by core by. The stopped core
+// does not count as halted last, as it never executed HALT.
+func (c *multiCore) stop(i, by int) {
+	c.cores[i].halted = true
+	c.lastStop = by
+}
+
+func (c *multiCore) start(i int) { c.cores[i].halted = false }
+
+// allHalted returns a halt exception if all cores are halted, or nil
+// otherwise. The exception is on the core that executed HALT last. When
+// no core executed HALT, it is on the core that issued the last
+// CORESTOP.
+func (c *multiCore) allHalted() *CoreExcep {
+	for _, core := range c.cores {
+		if !core.halted {
+			return nil
+		}
+	}
+	switch {
+	case c.lastHalt >= 0:
+		return &CoreExcep{c.lastHalt, errHalt}
+	case c.lastStop >= 0:
+		return &CoreExcep{c.lastStop, errHalt}
+	}
+	// no core ran HALT or CORESTOP
+	return &CoreExcep{0, errHalt}
 }
 
 // Ncore returns the number of cores.
@@ -0,0 +1,126 @@
+package arch
+
+import (
+	"testing"
+
+	asminst "shanhu.io/smlvm/asm/inst"
+)
+
+// coreHaltTestInsts has core 1 wait for work from core 0.
+var coreHaltTestInsts = []uint32{
+	asminst.Imm(ADDI, R1, R0, CPUID),     // 8000: addi r1 r0 CPUID
+	asminst.Sys(SYSINFO, R1, R2),         // 8004: sysinfo r1 r2
+	asminst.Br(BNE, R1, R0, 7),           // 8008: bne r1 r0 .second
+	asminst.Imm(LUI, R2, 0, 1),           // 800c: lui r2 1
+	asminst.Imm(ADDI, R3, R0, 42),        // 8010: addi r3 r0 42
+	asminst.Imm(SW, R3, R2, 0),           // 8014: sw r3 r2
+	asminst.Imm(ADDI, R1, R0, CORESTART), // 8018: addi r1 r0 CORESTART
+	asminst.Imm(ADDI, R2, R0, 1),         // 801c: addi r2 r0 1
+	asminst.Sys(SYSINFO, R1, R2),         // 8020: sysinfo r1 r2
+	asminst.Sys(HALT, 0, 0),              // 8024: halt
+	asminst.Sys(HALT, 0, 0),              // 8028: .second: halt
+	asminst.Imm(LUI, R2, 0, 1),           // 802c: lui r2 1
+	asminst.Imm(LW, R3, R2, 0),           // 8030: lw r3 r2
+	asminst.Imm(ADDI, R3, R3, 1),         // 8034: addi r3 r3 1
+	asminst.Imm(SW, R3, R2, 4),           // 8038: sw r3 r2 4
+	asminst.Sys(HALT, 0, 0),              // 803c: halt
+}
+
+func TestCoreHalt(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		m := newTestMachineConfig(t, &Config{
+			Ncore:    2,
+			Parallel: parallel,
+			Quantum:  3,
+		}, coreHaltTestInsts)
+		n, e := m.Run(1000)
+		if e == nil || e.Core != 1 || !IsHalt(e) {
+			t.Fatalf("parallel=%t: want halt on core 1, got %v",
+				parallel, e)
+		}
+		if !parallel && n != 13 {
+			t.Errorf("ran %d ticks, want 13", n)
+		}
+		if got, _ := m.phyMem.ReadWord(0x10004); got != 43 {
+			t.Errorf("parallel=%t: got %d, want 43", parallel, got)
+		}
+		if pc := m.DumpRegs(0)[PC]; pc != 0x8028 {
+			t.Errorf("parallel=%t: core 0 halted at %08x", parallel, pc)
+		}
+
+		// a halted machine stays halted
+		if _, e := m.Run(10); !IsHalt(e) {
+			t.Errorf("parallel=%t: want halt again, got %v", parallel, e)
+		}
+	}
+}
+
+func TestCoreStop(t *testing.T) {
+	insts := []uint32{
+		asminst.Imm(ADDI, R1, R0, CPUID),    // 8000: addi r1 r0 CPUID
+		asminst.Sys(SYSINFO, R1, R2),        // 8004: sysinfo r1 r2
+		asminst.Br(BNE, R1, R0, 4),          // 8008: bne r1 r0 .loop
+		asminst.Imm(ADDI, R1, R0, CORESTOP), // 800c: addi r1 r0 CORESTOP
+		asminst.Imm(ADDI, R2, R0, 1),        // 8010: addi r2 r0 1
+		asminst.Sys(SYSINFO, R1, R2),        // 8014: sysinfo r1 r2
+		asminst.Sys(HALT, 0, 0),             // 8018: halt
+		asminst.Jmp(J, -1),                  // 801c: .loop: j .loop
+	}
+	m := newTestMachine(t, 2, insts)
+	if _, e := m.Run(1000); e == nil || e.Core != 0 || !IsHalt(e) {
+		t.Fatalf("want halt on core 0, got %v", e)
+	}
+	if r1 := m.DumpRegs(0)[R1]; r1 != 0 {
+		t.Errorf("stop returned %d", r1)
+	}
+	if pc := m.DumpRegs(1)[PC]; pc != 0x801c {
+		t.Errorf("core 1 stopped at %08x", pc)
+	}
+
+	// a core stopped by SYSINFO does not report the halt
+	stopSelf := []uint32{
+		asminst.Imm(ADDI, R1, R0, CPUID),    // 8000: addi r1 r0 CPUID
+		asminst.Sys(SYSINFO, R1, R2),        // 8004: sysinfo r1 r2
+		asminst.Br(BNE, R1, R0, 4),          // 8008: bne r1 r0 .second
+		asminst.Imm(ADDI, R1, R0, CORESTOP), // 800c: addi r1 r0 CORESTOP
+		asminst.Imm(ADDI, R2, R0, 0),        // 8010: addi r2 r0 0
+		asminst.Sys(SYSINFO, R1, R2),        // 8014: sysinfo r1 r2
+		asminst.Jmp(J, -1),                  // 8018: j .
+		asminst.Sys(HALT, 0, 0),             // 801c: .second: halt
+	}
+	m = newTestMachine(t, 2, stopSelf)
+	if _, e := m.Run(1000); e == nil || e.Core != 1 || !IsHalt(e) {
+		t.Errorf("want halt on core 1, got %v", e)
+	}
+
+	// with no HALT, the core that issued the last CORESTOP reports
+	stopAll := []uint32{
+		asminst.Imm(ADDI, R1, R0, CPUID),    // 8000: addi r1 r0 CPUID
+		asminst.Sys(SYSINFO, R1, R2),        // 8004: sysinfo r1 r2
+		asminst.Br(BNE, R1, R0, 1),          // 8008: bne r1 r0 .second
+		asminst.Jmp(J, -1),                  // 800c: j .
+		asminst.Imm(ADDI, R1, R0, CORESTOP), // 8010: .second: addi r1 r0 CORESTOP
+		asminst.Imm(ADDI, R2, R0, 0),        // 8014: addi r2 r0 0
+		asminst.Sys(SYSINFO, R1, R2),        // 8018: sysinfo r1 r2
+		asminst.Imm(ADDI, R1, R0, CORESTOP), // 801c: addi r1 r0 CORESTOP
+		asminst.Imm(ADDI, R2, R0, 1),        // 8020: addi r2 r0 1
+		asminst.Sys(SYSINFO, R1, R2),        // 8024: sysinfo r1 r2
+	}
+	for _, parallel := range []bool{false, true} {
+		m = newTestMachineConfig(t, &Config{
+			Ncore:    2,
+			Parallel: parallel,
+		}, stopAll)
+		if _, e := m.Run(1000); e == nil || e.Core != 1 || !IsHalt(e) {
+			t.Errorf("parallel=%t: want halt on core 1, got %v",
+				parallel, e)
+		}
+	}
+
+	m = newTestMachineConfig(t, &Config{}, insts)
+	m.cores.cores[0].ring = 1
+	m.cores.cores[0].regs[PC] = 0x800c
+	if _, e := m.Run(10); e == nil || e.Code != ErrInvalidInst {
+		t.Errorf("want invalid instruction in user mode, got %v", e)
+	}
+}
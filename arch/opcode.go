@@ -14,6 +14,9 @@ const (
 	LBU = 9
 	SW  = 10
 	SB  = 11
+	LH  = 12
+	LHU = 13
+	SH  = 14
 )
 
 // Register instructions
@@ -40,11 +43,29 @@ const (
 	MOD   = 19
 	MODU  = 20
 
-	FADD = 0
-	FSUB = 1
-	FMUL = 2
-	FDIV = 3
-	FINT = 4
+	// Atomic memory instructions. The address is in src1. The old
+	// word in memory is loaded into dest.
+	CAS     = 21 // stores src2 if the old word equals dest
+	AMOADD  = 22 // stores the old word plus src2
+	AMOSWAP = 23 // stores src2
+
+	MULH  = 24 // high word of the signed product
+	MULHU = 25 // high word of the unsigned product
+
+	// Float instructions, with the float bit set. The operands and
+	// the results are float32 bits, except for the ones noted.
+	FADD  = 0
+	FSUB  = 1
+	FMUL  = 2
+	FDIV  = 3
+	FINT  = 4  // converts src1 to an int, truncating
+	ITOF  = 5  // converts int src1 to a float
+	FEQ   = 6  // sets dest to 1 if src1 == src2, 0 otherwise
+	FLT   = 7  // sets dest to 1 if src1 < src2, 0 otherwise
+	FLE   = 8  // sets dest to 1 if src1 <= src2, 0 otherwise
+	FNEG  = 9  // negates src1
+	FABS  = 10 // absolute value of src1
+	FSQRT = 11 // square root of src1
 )
 
 // Branch instructions
@@ -63,6 +84,7 @@ const (
 	SYSINFO = 69
 	IOCALL  = 70
 	SLEEP   = 71
+	IPI     = 72 // interrupts the core in r1 with the code in r2
 )
 
 // Jump instructions
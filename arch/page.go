@@ -1,12 +1,23 @@
 package arch
 
+import (
+	"sync"
+	"sync/atomic"
+)
+
 // PageSize is the number of bytes a page contains.
 const PageSize = 4096
 
 // Page is a memory addressable area of PageSize bytes
 type page struct {
-	uints []uint32
-	dirty map[uint32]bool
+	uints   []uint32
+	journal *journal
+
+	dirtyLock sync.Mutex // cores write the page in parallel
+	dirty     map[uint32]bool
+
+	ptable   uint32  // 1 if the page has page table entries; atomic
+	tableGen *uint32 // bumped when a page table entry changes
 }
 
 // NewPage creates a new empty page.
@@ -18,10 +29,21 @@ func newPage() *page {
 
 func (p *page) trackDirty() { p.dirty = make(map[uint32]bool) }
 
+// markDirty marks n bytes starting at offset as dirty.
+func (p *page) markDirty(offset, n uint32) {
+	p.dirtyLock.Lock()
+	defer p.dirtyLock.Unlock()
+	for i := uint32(0); i < n; i++ {
+		p.dirty[offset+i] = true
+	}
+}
+
 func (p *page) dirtyBytes() map[uint32]byte {
 	if p.dirty == nil {
 		return nil
 	}
+	p.dirtyLock.Lock()
+	defer p.dirtyLock.Unlock()
 	ret := make(map[uint32]byte)
 	for off := range p.dirty {
 		b := p.ReadByte(off)
@@ -46,13 +68,56 @@ func (p *page) WriteByte(offset uint32, b byte) {
 	offset %= PageSize
 	pos := offset / 4
 	shift := (offset % 4) * 8
+	if p.journal != nil {
+		p.journal.record(p, pos)
+	}
 	u := p.uints[pos]
 	u &= ^(uint32(0xff) << shift)
 	u |= uint32(b) << shift
-	p.uints[pos] = u
+	p.set(pos, u)
 
 	if p.dirty != nil {
-		p.dirty[offset] = true
+		p.markDirty(offset, 1)
+	}
+}
+
+// ReadHalf reads a halfword at the particular offset.
+// When offset is not 2-byte aligned, it aligns down.
+func (p *page) ReadHalf(offset uint32) uint16 {
+	offset %= PageSize
+	shift := (offset % 4 / 2) * 16
+	return uint16(p.uints[offset/4] >> shift)
+}
+
+// WriteHalf writes a halfword into the page at a particular offset.
+// When offset is not 2-byte aligned, it aligns down.
+func (p *page) WriteHalf(offset uint32, h uint16) {
+	offset %= PageSize
+	offset &^= 1
+	pos := offset / 4
+	shift := (offset % 4) * 8
+	if p.journal != nil {
+		p.journal.record(p, pos)
+	}
+	u := p.uints[pos]
+	u &= ^(uint32(0xffff) << shift)
+	u |= uint32(h) << shift
+	p.set(pos, u)
+
+	if p.dirty != nil {
+		p.markDirty(offset, 2)
+	}
+}
+
+// set sets the word at the position, and invalidates the caches of
+// the word.
+func (p *page) set(pos uint32, w uint32) {
+	if p.uints[pos] == w {
+		return
+	}
+	p.uints[pos] = w
+	if p.tableGen != nil && atomic.LoadUint32(&p.ptable) != 0 {
+		atomic.AddUint32(p.tableGen, 1)
 	}
 }
 
@@ -67,13 +132,14 @@ func (p *page) ReadWord(offset uint32) uint32 {
 // When offset is larger than offset, it uses the modular.
 // When offset is not 4-byte aligned, it aligns down.
 func (p *page) WriteWord(offset uint32, w uint32) {
-	p.uints[(offset%PageSize)/4] = w
+	pos := (offset % PageSize) / 4
+	if p.journal != nil {
+		p.journal.record(p, pos)
+	}
+	p.set(pos, w)
 
 	if p.dirty != nil {
-		p.dirty[offset] = true
-		p.dirty[offset+1] = true
-		p.dirty[offset+2] = true
-		p.dirty[offset+3] = true
+		p.markDirty(offset, 4)
 	}
 }
 
@@ -0,0 +1,197 @@
+package arch
+
+import (
+	"sync"
+)
+
+// DefaultQuantum is the default number of ticks that the cores run in
+// parallel between two synchronizations.
+const DefaultQuantum = 1000
+
+// errYield is returned by a core that needs to execute an IOCALL when
+// the cores are running in parallel. It never leaves the machine.
+var errYield = newExcep(0, "yield")
+
+// coreRun is the progress of a core in a quantum.
+type coreRun struct {
+	left   int // ticks left in the quantum
+	pos    int // ticks run in the quantum
+	e      *Excep
+	yield  bool
+	halted bool // halted in the quantum
+}
+
+// step counts a tick that returned e. It returns true if the core can
+// keep running in the quantum. A halted core waits for the next quantum
+// even when it is started again.
+func (r *coreRun) step(c *cpu, e *Excep) bool {
+	r.left--
+	r.pos++
+	if e != nil && e.Code == ErrHalt {
+		c.halt()
+		r.halted = true
+		r.left = 0
+		return false
+	}
+	if e != nil {
+		r.e = e
+		return false
+	}
+	return r.left > 0 && !c.halted
+}
+
+func (r *coreRun) run(c *cpu) {
+	for r.left > 0 {
+		e := c.Tick()
+		if e == errYield {
+			r.yield = true
+			return
+		}
+		if !r.step(c, e) {
+			return
+		}
+	}
+}
+
+func (m *Machine) parallel() bool {
+	if m.quantum <= 0 || len(m.cores.cores) <= 1 {
+		return false
+	}
+	if m.profiler != nil || m.phyMem.journal != nil || m.inputs != nil {
+		return false
+	}
+	return m.cores.cores[0].tracer == nil
+}
+
+func (m *Machine) setYieldIO(yield bool) {
+	for _, c := range m.cores.cores {
+		c.yieldIO = yield
+	}
+}
+
+// runQuantum runs the cores in parallel for q ticks. IOCALLs are
+// executed between the parallel runs, one core at a time. Devices tick
+// between the runs too, catching up with the slowest core that is still
+// running. It returns the ticks run before the earliest exception in
+// the quantum.
+func (m *Machine) runQuantum(q int) (int, *CoreExcep) {
+	dev := 0 // device ticks in the quantum
+	tickTo := func(pos int) {
+		for ; dev < pos; dev++ {
+			m.tickDevices()
+		}
+	}
+	tickTo(1)
+
+	cores := m.cores.cores
+	runs := make([]*coreRun, len(cores))
+	for i, c := range cores {
+		runs[i] = &coreRun{left: q}
+		if c.halted {
+			runs[i].left = 0
+		}
+	}
+
+	for {
+		var wg sync.WaitGroup
+		for i, c := range cores {
+			r := runs[i]
+			if r.left == 0 || r.e != nil || c.halted {
+				continue
+			}
+			wg.Add(1)
+			go func(r *coreRun, c *cpu) {
+				defer wg.Done()
+				r.run(c)
+			}(r, c)
+		}
+		wg.Wait()
+
+		next := q
+		for _, r := range runs {
+			if r.yield && r.pos+1 < next {
+				next = r.pos + 1
+			}
+		}
+		tickTo(next)
+
+		busy := false
+		for i, c := range cores {
+			r := runs[i]
+			if !r.yield {
+				continue
+			}
+			r.yield = false
+
+			c.yieldIO = false
+			e := c.Tick()
+			c.yieldIO = true
+			if r.step(c, e) {
+				busy = true
+			}
+		}
+		if !busy {
+			break
+		}
+	}
+	tickTo(q)
+
+	var ret *CoreExcep
+	pos := q
+	for i, r := range runs {
+		if r.e != nil && (ret == nil || r.pos < pos) {
+			ret = &CoreExcep{i, r.e}
+			pos = r.pos
+		}
+	}
+	if ret != nil {
+		return pos, ret
+	}
+
+	last := -1
+	for i, r := range runs {
+		if !r.halted || !cores[i].halted {
+			continue
+		}
+		if last < 0 || r.pos >= runs[last].pos {
+			last = i
+		}
+	}
+	if last >= 0 {
+		m.cores.lastHalt = last
+	}
+	if e := m.cores.allHalted(); e != nil {
+		pos = 0
+		for _, r := range runs {
+			if r.pos > pos {
+				pos = r.pos
+			}
+		}
+		return pos, e
+	}
+	return q, nil
+}
+
+// runParallel runs the machine in quanta, where each core runs on its
+// own goroutine. Devices tick where the cores synchronize, so interrupts
+// are delivered at IOCALLs and at the quantum boundaries. When a core
+// throws an exception, the other cores still finish the quantum.
+func (m *Machine) runParallel(nticks int) (int, *CoreExcep) {
+	m.setYieldIO(true)
+	defer m.setYieldIO(false)
+
+	n := 0
+	for nticks == 0 || n < nticks {
+		q := m.quantum
+		if nticks > 0 && nticks-n < q {
+			q = nticks - n
+		}
+		k, e := m.runQuantum(q)
+		n += k
+		if e != nil {
+			m.FlushScreen()
+			return n, e
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,148 @@
+package arch
+
+import (
+	"testing"
+
+	asminst "shanhu.io/smlvm/asm/inst"
+)
+
+func TestParallel(t *testing.T) {
+	const ncore = 8
+	insts := []uint32{
+		asminst.Imm(LUI, R2, 0, 1),            // 8000: lui r2 1
+		asminst.Imm(ADDI, R3, R0, 1000),       // 8004: addi r3 r0 1000
+		asminst.Imm(ADDI, R4, R0, 1),          // 8008: addi r4 r0 1
+		asminst.Sys(IOCALL, 0, 0),             // 800c: .loop: iocall
+		asminst.Reg(AMOADD, R1, R2, R4, 0, 0), // 8010: amoadd r1 r2 r4
+		asminst.Imm(ADDI, R3, R3, 0xffff),     // 8014: addi r3 r3 -1
+		asminst.Br(BNE, R3, R0, -4),           // 8018: bne r3 r0 .loop
+		asminst.Jmp(J, -1),                    // 801c: .end: j .end
+	}
+
+	for _, q := range []int{0, 1, 7} {
+		m := newTestMachineConfig(t, &Config{
+			Ncore:    ncore,
+			Parallel: true,
+			Quantum:  q,
+		}, insts)
+		n, e := m.Run(10000)
+		if e != nil {
+			t.Fatalf("quantum %d: unexpected exception: %v", q, e)
+		}
+		if n != 10000 {
+			t.Errorf("quantum %d: ran %d ticks, want 10000", q, n)
+		}
+		if got, _ := m.phyMem.ReadWord(0x10000); got != 1000*ncore {
+			t.Errorf("quantum %d: got %d, want %d", q, got, 1000*ncore)
+		}
+		for i, c := range m.cores.cores {
+			if c.ncycle != 10000 {
+				t.Errorf("quantum %d: core %d ran %d cycles", q, i, c.ncycle)
+			}
+		}
+	}
+
+	// the earliest exception stops the machine
+	insts = []uint32{
+		asminst.Imm(ADDI, R1, R0, CPUID),  // 8000: addi r1 r0 CPUID
+		asminst.Sys(SYSINFO, R1, R2),      // 8004: sysinfo r1 r2
+		asminst.Br(BNE, R1, R0, 1),        // 8008: bne r1 r0 .end
+		asminst.Reg(PANIC, 0, 0, 0, 0, 0), // 800c: panic
+		asminst.Jmp(J, -1),                // 8010: .end: j .end
+	}
+	m := newTestMachineConfig(t, &Config{
+		Ncore:    ncore,
+		Parallel: true,
+		Quantum:  100,
+	}, insts)
+	n, e := m.Run(10000)
+	if e == nil || e.Core != 0 || !IsPanic(e) {
+		t.Fatalf("want panic on core 0, got %v", e)
+	}
+	if n != 4 {
+		t.Errorf("ran %d ticks, want 4", n)
+	}
+}
+
+// tickProbe is a device that records how far a core is behind or ahead
+// of the device ticks.
+type tickProbe struct {
+	c      *cpu
+	n      int // device ticks
+	minLag int
+	maxLag int
+}
+
+func (p *tickProbe) Tick() {
+	lag := int(p.c.ncycle) - p.n
+	if p.n == 0 || lag < p.minLag {
+		p.minLag = lag
+	}
+	if p.n == 0 || lag > p.maxLag {
+		p.maxLag = lag
+	}
+	p.n++
+}
+
+func TestParallelDeviceTicks(t *testing.T) {
+	insts := []uint32{
+		asminst.Sys(IOCALL, 0, 0), // 8000: .loop: iocall
+		asminst.Jmp(J, -2),        // 8004: j .loop
+	}
+	m := newTestMachineConfig(t, &Config{
+		Ncore:    4,
+		Parallel: true,
+		Quantum:  100,
+	}, insts)
+	p := &tickProbe{c: m.cores.cores[0]}
+	m.addDevice(p)
+
+	if _, e := m.Run(1000); e != nil {
+		t.Fatal(e)
+	}
+	if p.n != 1000 {
+		t.Errorf("devices ticked %d times, want 1000", p.n)
+	}
+	// devices tick at the iocalls rather than all at the start of a
+	// quantum
+	if p.minLag < 0 || p.maxLag > 2 {
+		t.Errorf("core runs %d to %d ticks from the devices",
+			p.minLag, p.maxLag,
+		)
+	}
+}
+
+func TestParallelPaging(t *testing.T) {
+	// run with -race; cores walk the same page tables in parallel
+	const ncore = 4
+	insts := []uint32{
+		asminst.Imm(ADDI, R1, R0, CPUID),   // 8000: addi r1 r0 CPUID
+		asminst.Sys(SYSINFO, R1, R2),       // 8004: sysinfo r1 r2
+		asminst.Reg(SLL, R1, R1, 0, 12, 0), // 8008: sll r1 r1 12
+		asminst.Imm(LUI, R2, 0, 1),         // 800c: lui r2 1
+		asminst.Reg(ADD, R2, R2, R1, 0, 0), // 8010: add r2 r2 r1
+		asminst.Imm(ADDI, R3, R3, 1),       // 8014: .loop: addi r3 r3 1
+		asminst.Imm(SW, R3, R2, 0),         // 8018: sw r3 r2
+		asminst.Jmp(J, -3),                 // 801c: j .loop
+	}
+	m := newTestMachineConfig(t, &Config{
+		Ncore:    ncore,
+		Parallel: true,
+		Quantum:  10,
+	}, insts)
+	const root = 0x100000
+	identityMap(m, root, 64)
+	for _, c := range m.cores.cores {
+		c.virtMem.SetTable(root)
+	}
+
+	if _, e := m.Run(1000); e != nil {
+		t.Fatal(e)
+	}
+	for i := uint32(0); i < ncore; i++ {
+		addr := 0x10000 + i*PageSize
+		if got, _ := m.phyMem.ReadWord(addr); got == 0 {
+			t.Errorf("core %d did not write %x", i, addr)
+		}
+	}
+}
@@ -2,12 +2,21 @@ package arch
 
 import (
 	"math"
+	"sync"
+	"sync/atomic"
 )
 
 // PhyMemory is a collection of contiguous pages.
 type phyMemory struct {
-	npage uint32
-	pages map[uint32]*page
+	npage   uint32
+	pages   map[uint32]*page
+	journal *journal
+
+	tableGen uint32 // bumped when a page table entry changes
+
+	shared   bool       // if cores access the memory in parallel
+	mu       sync.Mutex // guards pages when shared
+	atomicMu sync.Mutex // serializes the atomic instructions
 }
 
 // NewPhyMemory creates a physical memory of size bytes.
@@ -41,17 +50,38 @@ func (pm *phyMemory) Page(pn uint32) *page {
 	if pn == 0 || pn >= pm.npage {
 		return nil // out of range
 	}
+	if pm.shared {
+		pm.mu.Lock()
+		defer pm.mu.Unlock()
+	}
 
 	ret, found := pm.pages[pn]
 	if !found {
 		// create an empty page on demand
 		ret = newPage()
+		ret.journal = pm.journal
+		ret.tableGen = &pm.tableGen
 		pm.pages[pn] = ret
 	}
 
 	return ret
 }
 
+// tableGeneration returns the generation of the page tables. TLBs
+// that are filled in an older generation are stale.
+func (pm *phyMemory) tableGeneration() uint32 {
+	return atomic.LoadUint32(&pm.tableGen)
+}
+
+// markTable marks the page that has the address as a page table page,
+// so that changes to the page invalidate the TLBs. Cores mark the pages
+// in parallel, so the mark is atomic.
+func (pm *phyMemory) markTable(addr uint32) {
+	if p := pm.Page(addr / PageSize); p != nil {
+		atomic.StoreUint32(&p.ptable, 1)
+	}
+}
+
 func (pm *phyMemory) pageForByte(addr uint32) (*page, *Excep) {
 	p := pm.Page(addr / PageSize)
 	if p == nil {
@@ -108,3 +138,16 @@ func (pm *phyMemory) WriteWord(addr uint32, v uint32) *Excep {
 	p.WriteWord(addr, v)
 	return nil
 }
+
+// ReadAt reads len(p) bytes at the given address.
+// If the bytes are out of range, it returns false.
+func (pm *phyMemory) ReadAt(p []byte, addr uint32) bool {
+	for i := range p {
+		b, e := pm.ReadByte(addr + uint32(i))
+		if e != nil {
+			return false
+		}
+		p[i] = b
+	}
+	return true
+}
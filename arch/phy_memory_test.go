@@ -1,6 +1,7 @@
 package arch
 
 import (
+	"bytes"
 	"testing"
 )
 
@@ -49,4 +50,9 @@ func TestPhyMemory(t *testing.T) {
 	b, e := m.ReadByte(off + 2)
 	as(e == nil)
 	eo(b != 0x5a, "expect 0x5a got %02x", b)
+
+	bs := make([]byte, 3)
+	as(m.ReadAt(bs, off+1))
+	eo(!bytes.Equal(bs, []byte{0x21, 0x5a, 0x70}), "got %v", bs)
+	eo(m.ReadAt(bs, size-2), "read at the end should be out of range")
 }
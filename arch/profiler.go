@@ -0,0 +1,144 @@
+package arch
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"shanhu.io/smlvm/debug"
+	"shanhu.io/smlvm/profile"
+)
+
+// DefaultProfilePeriod is the default number of ticks between two
+// samples of a profiler.
+const DefaultProfilePeriod = 100
+
+// Profiler samples the call stacks of the cores of a machine every
+// fixed number of ticks, and attributes the cycles that the core runs
+// since the last sample to the sampled call stack.
+type Profiler struct {
+	period int
+	n      int
+
+	loaded bool
+	table  *debug.Table
+	funcs  []*funcEntry
+
+	last    []uint64 // cycle counts of the cores at the last sample
+	samples map[string]*profile.Sample
+}
+
+// NewProfiler creates a profiler that samples every period ticks.
+// It uses DefaultProfilePeriod if period is not positive.
+func NewProfiler(period int) *Profiler {
+	if period <= 0 {
+		period = DefaultProfilePeriod
+	}
+	return &Profiler{
+		period:  period,
+		samples: make(map[string]*profile.Sample),
+	}
+}
+
+// SetProfiler sets the profiler of the machine. p can be nil to stop
+// profiling.
+func (m *Machine) SetProfiler(p *Profiler) { m.profiler = p }
+
+func (p *Profiler) load(m *Machine) {
+	p.loaded = true
+	sec := debugSection(m.Sections)
+	if sec == nil {
+		return
+	}
+	t, err := debug.UnmarshalTable(sec.Bytes)
+	if err != nil {
+		return
+	}
+	p.table = t
+	p.funcs = sortTable(t)
+}
+
+func (p *Profiler) tick(m *Machine) {
+	p.n++
+	if p.n < p.period {
+		return
+	}
+	p.n = 0
+
+	if !p.loaded {
+		p.load(m)
+	}
+	for i, c := range m.cores.cores {
+		if i >= len(p.last) {
+			p.last = append(p.last, 0)
+		}
+		delta := c.ncycle - p.last[i]
+		p.last[i] = c.ncycle
+		if delta == 0 {
+			continue // sleeping
+		}
+		p.sample(m, byte(i), int64(delta))
+	}
+}
+
+func (p *Profiler) stack(m *Machine, core byte) []*profile.Frame {
+	if p.table == nil {
+		pc := m.DumpRegs(core)[PC]
+		return []*profile.Frame{{PC: pc}}
+	}
+
+	var ret []*profile.Frame
+	walkStack(m, core, p.table, p.funcs,
+		func(pc uint32, name string, f *debug.Func) bool {
+			frame := &profile.Frame{PC: pc, Func: name}
+			if f != nil && f.Pos != nil {
+				frame.File = f.Pos.File
+				frame.Line = f.Pos.Line
+			}
+			ret = append(ret, frame)
+			return true
+		},
+	) // a broken stack is still sampled with the frames walked
+	return ret
+}
+
+func stackKey(stack []*profile.Frame) string {
+	var bs []byte
+	for _, f := range stack {
+		bs = append(bs, fmt.Sprintf("%08x;", f.PC)...)
+	}
+	return string(bs)
+}
+
+func (p *Profiler) sample(m *Machine, core byte, cycles int64) {
+	stack := p.stack(m, core)
+	k := stackKey(stack)
+	s, found := p.samples[k]
+	if !found {
+		s = &profile.Sample{Stack: stack}
+		p.samples[k] = s
+	}
+	s.Count++
+	s.Cycles += cycles
+}
+
+// Profile returns the samples collected so far.
+func (p *Profiler) Profile() *profile.Profile {
+	var keys []string
+	for k := range p.samples {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	ret := &profile.Profile{Period: int64(p.period)}
+	for _, k := range keys {
+		ret.Samples = append(ret.Samples, p.samples[k])
+	}
+	return ret
+}
+
+// WriteProfile writes the samples collected so far in the pprof
+// format.
+func (p *Profiler) WriteProfile(w io.Writer) error {
+	return p.Profile().Write(w)
+}
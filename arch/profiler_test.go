@@ -0,0 +1,54 @@
+package arch
+
+import (
+	"testing"
+
+	asminst "shanhu.io/smlvm/asm/inst"
+	"shanhu.io/smlvm/debug"
+	"shanhu.io/smlvm/image"
+)
+
+func TestProfiler(t *testing.T) {
+	insts := []uint32{
+		asminst.Jmp(JAL, 1),                // 8000: jal f
+		asminst.Sys(HALT, 0, 0),            // 8004: halt
+		asminst.Imm(ADDI, R3, R0, 50),      // 8008: f: addi r3 r0 50
+		asminst.Imm(ADDI, R1, R1, 1),       // 800c: .loop: addi r1 r1 1
+		asminst.Br(BNE, R1, R3, -2),        // 8010: bne r1 r3 .loop
+		asminst.Reg(SLL, PC, RET, 0, 0, 0), // 8014: mov pc ret
+	}
+	m := newTestMachine(t, 1, insts)
+
+	tab := debug.NewTable()
+	tab.Funcs["main.main"] = &debug.Func{Start: 0x8000, Size: 8}
+	tab.Funcs["main.f"] = &debug.Func{Start: 0x8008, Size: 16}
+	m.Sections = []*image.Section{{
+		Header: &image.Header{Type: image.Debug},
+		Bytes:  tab.Marshal(),
+	}}
+
+	p := NewProfiler(10)
+	m.SetProfiler(p)
+	if _, e := m.Run(1000); !IsHalt(e) {
+		t.Fatalf("want halt, got %v", e)
+	}
+
+	prof := p.Profile()
+	var inF, cycles int64
+	for _, s := range prof.Samples {
+		cycles += s.Cycles
+		if s.Stack[0].Func != "main.f" {
+			continue
+		}
+		inF += s.Count
+		if len(s.Stack) != 2 || s.Stack[1].Func != "main.main" {
+			t.Errorf("bad stack of main.f: %d frames", len(s.Stack))
+		}
+	}
+	if inF != 10 {
+		t.Errorf("got %d samples in main.f, want 10", inF)
+	}
+	if cycles != 100 {
+		t.Errorf("got %d cycles sampled, want 100", cycles)
+	}
+}
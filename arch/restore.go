@@ -0,0 +1,166 @@
+package arch
+
+import (
+	"container/list"
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"shanhu.io/smlvm/arch/misc"
+)
+
+func (c *cpu) restore(s *cpuSnap) {
+	copy(c.regs, s.Regs)
+	c.ring = s.Ring
+	c.ncycle = s.Ncycle
+	c.sleeping = s.Sleeping
+	c.halted = s.Halted
+	c.virtMem.SetTable(s.PTRoot)
+}
+
+func (c *calls) restore(s *callsSnap) {
+	c.queue = list.New()
+	for _, m := range s.Queue {
+		c.queue.PushBack(&callsMessage{service: m.Service, p: m.P})
+	}
+	c.timedSleep = s.TimedSleep
+	c.sleep = s.Sleep
+	for _, id := range s.Muted {
+		c.enabled[id] = false
+	}
+}
+
+func (t *timer) restore(s *timerSnap) {
+	t.now = s.Now
+	for i, e := range s.Timers {
+		t.timers[i] = timerEntry{
+			flags:  e.Flags,
+			code:   e.Code,
+			period: e.Period,
+			left:   e.Left,
+		}
+	}
+}
+
+func (r *rom) restore(s *romSnap) {
+	r.state = s.State
+	r.countDown = s.CountDown
+	r.addr = s.Addr
+	r.bs = s.Bs
+	r.err = s.Err
+	r.Core = s.Core
+	r.IntDone = s.IntDone
+}
+
+func (d *disk) restore(s *diskSnap) {
+	d.state = s.State
+	d.countDown = s.CountDown
+	d.cmd = s.Cmd
+	d.block = s.Block
+	d.count = s.Count
+	d.addr = s.Addr
+	d.err = s.Err
+	d.Core = s.Core
+	d.IntDone = s.IntDone
+}
+
+// RestoreMachine restores a machine from a snapshot written by
+// Machine.Snapshot, with default host bindings.
+func RestoreMachine(r io.Reader) (*Machine, error) {
+	return RestoreMachineConfig(r, nil)
+}
+
+// RestoreMachineConfig restores a machine from a snapshot written by
+// Machine.Snapshot. Only the host bindings in the config are used, i.e.
+// Output, Screen, Table, Display, PerfNow, ROM and Disk, which overwrite
+// the ROM root directory and the disk image path saved in the snapshot.
+// The content of the disk image is not part of the snapshot. c can be
+// nil.
+func RestoreMachineConfig(r io.Reader, c *Config) (*Machine, error) {
+	s := new(snapshot)
+	if err := gob.NewDecoder(r).Decode(s); err != nil {
+		return nil, err
+	}
+	if s.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version: %d", s.Version)
+	}
+	if len(s.Cores) == 0 || len(s.Cores) > 32 {
+		return nil, fmt.Errorf("invalid number of cores: %d", len(s.Cores))
+	}
+
+	config := new(Config)
+	if c != nil {
+		*config = *c
+	}
+	config.MemSize = s.MemSize
+	config.Ncore = len(s.Cores)
+	config.RandSeed = s.Rand.Seed
+	config.LegacyDivZero = s.LegacyDivZero
+	if s.Timer != nil {
+		config.TickTime = s.Timer.TickTime
+	}
+	if config.ROM == "" && s.ROM != nil {
+		config.ROM = s.ROM.Root
+	}
+	if config.Disk == "" && s.Disk != nil {
+		config.Disk = s.Disk.Path
+	}
+	m := NewMachine(config)
+
+	// devices are holding the pages, so the pages are overwritten in
+	// place rather than replaced.
+	for pn, p := range m.phyMem.pages {
+		if _, found := s.Pages[pn]; !found {
+			copy(p.uints, make([]uint32, len(p.uints)))
+		}
+	}
+	for pn, uints := range s.Pages {
+		p := m.phyMem.Page(pn)
+		if p == nil || len(uints) != len(p.uints) {
+			return nil, fmt.Errorf("invalid page %d", pn)
+		}
+		copy(p.uints, uints)
+	}
+
+	for i, cs := range s.Cores {
+		if len(cs.Regs) != Nreg {
+			return nil, fmt.Errorf("invalid registers on core %d", i)
+		}
+		m.cores.cores[i].restore(cs)
+	}
+	m.cores.lastHalt = s.LastHalt
+	m.cores.lastStop = s.LastStop
+
+	m.calls.restore(s.Calls)
+	m.ticker.nextTick = s.Ticker.NextTick
+	m.ticker.Interval = s.Ticker.Interval
+	m.ticker.Noise = s.Ticker.Noise
+	m.ticker.Code = s.Ticker.Code
+	m.ticker.seed(s.Ticker.Seed, s.Ticker.Draws)
+	m.rand = misc.NewRandAt(s.Rand.Seed, s.Rand.Read)
+	m.calls.register(serviceRand, "rand", m.rand)
+	if s.Timer != nil {
+		if len(s.Timer.Timers) != len(m.timer.timers) {
+			return nil, fmt.Errorf("invalid number of timers")
+		}
+		m.timer.restore(s.Timer)
+	}
+	m.console.Core = s.Console.Core
+	m.console.IntIn = s.Console.IntIn
+	m.console.IntOut = s.Console.IntOut
+	if s.ROM != nil && m.rom != nil {
+		m.rom.restore(s.ROM)
+	}
+	if s.Disk != nil && m.disk != nil {
+		m.disk.restore(s.Disk)
+	}
+	if s.Display != nil && m.display != nil {
+		m.display.SetState(s.Display)
+	}
+	if s.Keys != nil && m.keys != nil {
+		m.keys.SetMode(s.Keys.Mode)
+	}
+	m.Sections = s.Sections
+
+	return m, nil
+}
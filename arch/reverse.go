@@ -0,0 +1,131 @@
+package arch
+
+// cpuRecord saves the state of a core before a tick.
+type cpuRecord struct {
+	regs     [Nreg]uint32
+	ring     byte
+	ncycle   uint64
+	sleeping bool
+	halted   bool
+	ptRoot   uint32
+}
+
+// tickRecord saves what is needed to undo a tick.
+type tickRecord struct {
+	cores    []cpuRecord
+	nextTick int32
+	mem      []memUndo
+}
+
+// history is the undo log of the recent ticks.
+type history struct {
+	j       *journal
+	max     int
+	records []*tickRecord
+	cur     *tickRecord
+}
+
+func (c *cpu) record() cpuRecord {
+	ret := cpuRecord{
+		ring:     c.ring,
+		ncycle:   c.ncycle,
+		sleeping: c.sleeping,
+		halted:   c.halted,
+	}
+	copy(ret.regs[:], c.regs)
+	if c.virtMem.ptable != nil {
+		ret.ptRoot = c.virtMem.ptable.root
+	}
+	return ret
+}
+
+func (c *cpu) undo(r *cpuRecord) {
+	copy(c.regs, r.regs[:])
+	c.ring = r.ring
+	c.ncycle = r.ncycle
+	c.sleeping = r.sleeping
+	c.halted = r.halted
+	c.virtMem.SetTable(r.ptRoot)
+}
+
+// Record starts recording an undo log of at most n recent ticks, so
+// that the machine can step backwards. Recording with n <= 0 stops the
+// recording and drops the log.
+//
+// The undo log covers the cores, the memory and the ticker. Other device
+// states, such as the pending calls messages, are not rewound.
+func (d *Debugger) Record(n int) {
+	if n <= 0 {
+		d.hist = nil
+		d.m.phyMem.setJournal(nil)
+		return
+	}
+
+	if d.hist == nil {
+		d.hist = &history{j: new(journal)}
+		d.m.phyMem.setJournal(d.hist.j)
+	}
+	d.hist.max = n
+	d.hist.trim()
+}
+
+// Recorded returns the number of ticks that can be stepped back.
+func (d *Debugger) Recorded() int {
+	if d.hist == nil {
+		return 0
+	}
+	return len(d.hist.records)
+}
+
+func (h *history) trim() {
+	if n := len(h.records) - h.max; n > 0 {
+		h.records = h.records[n:]
+	}
+}
+
+func (d *Debugger) beginTick() {
+	h := d.hist
+	if h == nil {
+		return
+	}
+	r := &tickRecord{nextTick: d.m.ticker.nextTick}
+	for _, c := range d.m.cores.cores {
+		r.cores = append(r.cores, c.record())
+	}
+	h.cur = r
+	h.j.entries = nil
+}
+
+func (d *Debugger) endTick() {
+	h := d.hist
+	if h == nil || h.cur == nil {
+		return
+	}
+	h.cur.mem = h.j.take()
+	h.records = append(h.records, h.cur)
+	h.cur = nil
+	h.trim()
+}
+
+func (d *Debugger) undo(r *tickRecord) {
+	undoMem(r.mem)
+	for i, c := range d.m.cores.cores {
+		c.undo(&r.cores[i])
+	}
+	d.m.ticker.nextTick = r.nextTick
+}
+
+// StepBack undoes the last recorded tick. It returns false when there
+// is no tick to undo.
+func (d *Debugger) StepBack() bool {
+	h := d.hist
+	if h == nil || len(h.records) == 0 {
+		return false
+	}
+	n := len(h.records) - 1
+	d.undo(h.records[n])
+	h.records = h.records[:n]
+	d.resuming = true
+	d.hit = nil
+	return true
+}
@@ -0,0 +1,85 @@
+package arch
+
+// stepBackN undoes the last n recorded ticks.
+func (d *Debugger) stepBackN(n int) {
+	for i := 0; i < n; i++ {
+		d.StepBack()
+	}
+}
+
+// ReverseContinue steps backwards until a core reaches a breakpoint,
+// or the recorded history runs out. It returns the number of ticks
+// undone, and the breakpoint stop, which is nil if the history runs out.
+func (d *Debugger) ReverseContinue() (int, *Stop) {
+	n := 0
+	for d.StepBack() {
+		n++
+		if s := d.checkBreaks(); s != nil {
+			return n, s
+		}
+	}
+	return n, nil
+}
+
+// ReverseToWrite steps backwards to right before the last recorded tick
+// that writes the word at the virtual address of a core, so that the
+// core that writes is at the writing instruction. It returns the number
+// of ticks undone, and the index of the core that wrote the word, which
+// is -1 for a device. When no recorded write is found, it steps nothing
+// and returns false.
+func (d *Debugger) ReverseToWrite(core byte, addr uint32) (
+	int, int, bool,
+) {
+	h := d.hist
+	if h == nil {
+		return 0, 0, false
+	}
+
+	pa, e := d.cpu(core).virtMem.translate(addr)
+	if e != nil {
+		return 0, 0, false
+	}
+	p, found := d.m.phyMem.pages[pa/PageSize]
+	if !found {
+		return 0, 0, false
+	}
+	pos := (pa % PageSize) / 4
+
+	n := len(h.records)
+	for i := n - 1; i >= 0; i-- {
+		mem := h.records[i].mem
+		for j := len(mem) - 1; j >= 0; j-- {
+			if mem[j].p == p && mem[j].pos == pos {
+				d.stepBackN(n - i)
+				return n - i, mem[j].core, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// ReverseToRegWrite steps backwards to right before the last recorded
+// tick that changes the register of the core. It returns the number of
+// ticks undone. When no recorded change is found, it steps nothing and
+// returns false.
+func (d *Debugger) ReverseToRegWrite(core byte, reg int) (int, bool) {
+	h := d.hist
+	if h == nil {
+		return 0, false
+	}
+	if reg < 0 || reg >= Nreg {
+		panic("invalid register")
+	}
+
+	after := d.cpu(core).regs[reg]
+	n := len(h.records)
+	for i := n - 1; i >= 0; i-- {
+		before := h.records[i].cores[core].regs[reg]
+		if before != after {
+			d.stepBackN(n - i)
+			return n - i, true
+		}
+		after = before
+	}
+	return 0, false
+}
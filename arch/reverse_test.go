@@ -0,0 +1,74 @@
+package arch
+
+import (
+	"testing"
+
+	asminst "shanhu.io/smlvm/asm/inst"
+)
+
+func TestReverse(t *testing.T) {
+	insts := []uint32{
+		asminst.Imm(LUI, R2, 0, 1),        // 8000: lui r2 1
+		asminst.Imm(ADDI, R3, R0, 10),     // 8004: addi r3 r0 10
+		asminst.Imm(ADDI, R1, R1, 3),      // 8008: .loop: addi r1 r1 3
+		asminst.Imm(SW, R1, R2, 0),        // 800c: sw r1 r2
+		asminst.Imm(ADDI, R3, R3, 0xffff), // 8010: addi r3 r3 -1
+		asminst.Br(BNE, R3, R0, -4),       // 8014: bne r3 r0 .loop
+		asminst.Sys(HALT, 0, 0),           // 8018: halt
+	}
+	m := newTestMachine(t, 1, insts)
+	d := NewDebugger(m)
+	d.Record(1000)
+
+	_, s := d.Continue(1000)
+	if s == nil || s.Reason != StopExcep || !IsHalt(s.Excep) {
+		t.Fatalf("want halt, got %v", s)
+	}
+
+	n, core, ok := d.ReverseToWrite(0, 0x10000)
+	if !ok || core != 0 {
+		t.Fatalf("write not found, core=%d", core)
+	}
+	if n != 4 {
+		t.Errorf("stepped back %d ticks, want 4", n)
+	}
+	regs := d.Regs(0)
+	if regs[PC] != 0x800c || regs[R1] != 30 {
+		t.Errorf("pc=%08x r1=%d, want at the last store", regs[PC], regs[R1])
+	}
+	if v, _ := d.ReadWord(0, 0x10000); v != 27 {
+		t.Errorf("memory is %d before the last store, want 27", v)
+	}
+
+	if _, ok := d.ReverseToRegWrite(0, R3); !ok {
+		t.Fatal("register write not found")
+	}
+	if regs := d.Regs(0); regs[PC] != 0x8010 || regs[R3] != 2 {
+		t.Errorf("pc=%08x r3=%d, want at the decrement", regs[PC], regs[R3])
+	}
+
+	d.AddBreak(0x8008)
+	_, s = d.ReverseContinue()
+	if s == nil || s.Reason != StopBreak {
+		t.Fatalf("want breakpoint, got %v", s)
+	}
+	if r1 := d.Regs(0)[R1]; r1 != 24 {
+		t.Errorf("r1 is %d at the breakpoint, want 24", r1)
+	}
+
+	d.RemoveBreak(0x8008)
+	for d.StepBack() {
+	}
+	if pc := d.Regs(0)[PC]; pc != InitPC {
+		t.Errorf("pc is %08x at the start of history", pc)
+	}
+	if v, _ := d.ReadWord(0, 0x10000); v != 0 {
+		t.Errorf("memory is %d at the start of history", v)
+	}
+
+	d.Record(3)
+	d.Continue(10)
+	if n := d.Recorded(); n != 3 {
+		t.Errorf("recorded %d ticks, want 3", n)
+	}
+}
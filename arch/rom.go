@@ -1,6 +1,7 @@
 package arch
 
 import (
+	"errors"
 	"io"
 	"log"
 	"os"
@@ -54,6 +55,8 @@ type rom struct {
 
 	Core    byte
 	IntDone byte
+
+	inputs *inputLog
 }
 
 func newROM(p *page, mem *phyMemory, i intBus, root string) *rom {
@@ -114,6 +117,30 @@ func (r *rom) readFile() (byte, error) {
 	return 0, nil
 }
 
+// read reads the requested file. When replaying, the result is taken
+// from the input log rather than the file.
+func (r *rom) read() (byte, error) {
+	if r.inputs.replaying() {
+		bs, ok := r.inputs.must(inputROM)
+		if !ok || len(bs) == 0 {
+			return romErrRead, errors.New("rom read not in the input log")
+		}
+		if bs[0] == romErrNone {
+			r.addr = r.p.readWord(romAddr)
+			r.bs = bs[1:]
+		}
+		return bs[0], nil
+	}
+
+	code, err := r.readFile()
+	data := []byte{code}
+	if code == romErrNone {
+		data = append(data, r.bs...)
+	}
+	r.inputs.record(inputROM, data)
+	return code, err
+}
+
 func (r *rom) Tick() {
 	switch r.state {
 	case romStateIdle:
@@ -121,7 +148,7 @@ func (r *rom) Tick() {
 		if cmd != 0 {
 			r.state = romStateBusy
 
-			errCode, err := r.readFile()
+			errCode, err := r.read()
 			if err != nil && err != io.EOF {
 				log.Println(err)
 			}
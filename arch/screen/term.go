@@ -0,0 +1,119 @@
+package screen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// DefaultTermInterval is the default interval between two updates of a
+// terminal.
+const DefaultTermInterval = 30 * time.Millisecond
+
+// Term renders the screen on an ANSI terminal, with the mouse reporting
+// turned on. A color byte of 0 uses the default colors of the terminal.
+// Otherwise, the low 4 bits are the foreground color, and the high 4
+// bits are the background color, both in the 16 ANSI colors, where 8-15
+// are the bright ones.
+type Term struct {
+	w        io.Writer
+	text     [Height * Width]byte
+	color    [Height * Width]byte
+	interval time.Duration
+	last     time.Time
+}
+
+// NewTerm creates a terminal renderer that writes to w, which updates
+// at most once in an interval. DefaultTermInterval is used if interval
+// is 0.
+func NewTerm(w io.Writer, interval time.Duration) *Term {
+	if interval <= 0 {
+		interval = DefaultTermInterval
+	}
+	ret := &Term{w: w, interval: interval}
+	for i := range ret.text {
+		ret.text[i] = ' '
+	}
+	return ret
+}
+
+func (t *Term) write(s string) {
+	if _, err := io.WriteString(t.w, s); err != nil {
+		log.Print(err)
+	}
+}
+
+// Start clears the terminal, hides the cursor, and turns on the mouse
+// reporting.
+func (t *Term) Start() {
+	t.write("\x1b[?1049h\x1b[2J\x1b[?25l\x1b[?1000h\x1b[?1006h")
+}
+
+// Stop reverts what Start does.
+func (t *Term) Stop() {
+	t.write("\x1b[?1006l\x1b[?1000l\x1b[0m\x1b[?25h\x1b[?1049l")
+}
+
+// NeedUpdate returns true when the last update is an interval ago.
+func (t *Term) NeedUpdate() bool {
+	return time.Since(t.last) >= t.interval
+}
+
+func sgr(c byte) string {
+	if c == 0 {
+		return "\x1b[0m"
+	}
+	code := func(base, c int) int {
+		if c >= 8 {
+			return base + 60 + c - 8
+		}
+		return base + c
+	}
+	fg := code(30, int(c&0xf))
+	bg := code(40, int(c>>4))
+	return fmt.Sprintf("\x1b[0;%d;%dm", fg, bg)
+}
+
+func (t *Term) draw(m map[uint32]byte) {
+	buf := new(bytes.Buffer)
+	for pos := range m {
+		if pos >= Height*Width {
+			continue
+		}
+		line, col := pos/Width, pos%Width
+		fmt.Fprintf(buf, "\x1b[%d;%dH", line+1, col+1)
+		buf.WriteString(sgr(t.color[pos]))
+		c := t.text[pos]
+		if c < ' ' || c > '~' {
+			c = ' '
+		}
+		buf.WriteByte(c)
+	}
+	buf.WriteString("\x1b[0m")
+	if _, err := t.w.Write(buf.Bytes()); err != nil {
+		log.Print(err)
+	}
+	t.last = time.Now()
+}
+
+// UpdateText updates the characters on the terminal.
+func (t *Term) UpdateText(m map[uint32]byte) {
+	for pos, c := range m {
+		if pos < Height*Width {
+			t.text[pos] = c
+		}
+	}
+	t.draw(m)
+}
+
+// UpdateColor updates the colors on the terminal.
+func (t *Term) UpdateColor(m map[uint32]byte) {
+	for pos, c := range m {
+		if pos < Height*Width {
+			t.color[pos] = c
+		}
+	}
+	t.draw(m)
+}
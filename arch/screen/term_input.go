@@ -0,0 +1,246 @@
+package screen
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"shanhu.io/smlvm/arch/keyboard"
+)
+
+// ErrInterrupt is returned by TermInput when Ctrl-C is pressed, as a
+// terminal in raw mode does not turn it into a signal.
+var ErrInterrupt = errors.New("interrupted")
+
+// Input receives the input events of a terminal.
+type Input interface {
+	Click(line, col uint8)
+
+	// Key receives a key press. The key codes and the modifiers are
+	// defined in the keyboard package.
+	Key(code uint16, mods uint8)
+
+	// Text receives the text typed.
+	Text(s string)
+}
+
+// TermInput reads the input of an ANSI terminal in raw mode, with the
+// mouse reporting turned on by Term.Start. Left button presses are
+// clicks. Keys are decoded as xterm sends them, and printable
+// characters are also sent as text. Unknown escape sequences are
+// dropped. Ctrl-C is not a key press, but makes Next return
+// ErrInterrupt.
+type TermInput struct {
+	r *bufio.Reader
+}
+
+// NewTermInput creates a terminal input reader.
+func NewTermInput(r io.Reader) *TermInput {
+	return &TermInput{r: bufio.NewReader(r)}
+}
+
+// readSeq reads the rest of a control sequence after "ESC [", until a
+// final byte.
+func (t *TermInput) readSeq() (string, error) {
+	var buf []byte
+	for {
+		b, err := t.r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		buf = append(buf, b)
+		if b >= 0x40 && b <= 0x7e {
+			return string(buf), nil
+		}
+	}
+}
+
+func parseInts(s string) ([]int, bool) {
+	var ret []int
+	for _, f := range strings.Split(s, ";") {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, false
+		}
+		ret = append(ret, n)
+	}
+	return ret, true
+}
+
+// parseMouse parses a mouse report like "<0;12;5M", which is a left
+// button press at column 12 of line 5, both starting from 1.
+func parseMouse(seq string, in Input) {
+	if !strings.HasSuffix(seq, "M") {
+		return // not a press
+	}
+	v, ok := parseInts(seq[1 : len(seq)-1])
+	if !ok || len(v) != 3 {
+		return
+	}
+	button, col, line := v[0], v[1]-1, v[2]-1
+	if button != 0 || line < 0 || line >= Height || col < 0 ||
+		col >= Width {
+		return
+	}
+	in.Click(uint8(line), uint8(col))
+}
+
+var seqKeys = map[byte]uint16{
+	'A': keyboard.CodeUp,
+	'B': keyboard.CodeDown,
+	'C': keyboard.CodeRight,
+	'D': keyboard.CodeLeft,
+	'H': keyboard.CodeHome,
+	'F': keyboard.CodeEnd,
+	'P': keyboard.CodeF1,
+	'Q': keyboard.CodeF1 + 1,
+	'R': keyboard.CodeF1 + 2,
+	'S': keyboard.CodeF1 + 3,
+}
+
+var tildeKeys = map[int]uint16{
+	1:  keyboard.CodeHome,
+	2:  keyboard.CodeInsert,
+	3:  keyboard.CodeDelete,
+	4:  keyboard.CodeEnd,
+	5:  keyboard.CodePageUp,
+	6:  keyboard.CodePageDown,
+	15: keyboard.CodeF1 + 4,
+	17: keyboard.CodeF1 + 5,
+	18: keyboard.CodeF1 + 6,
+	19: keyboard.CodeF1 + 7,
+	20: keyboard.CodeF1 + 8,
+	21: keyboard.CodeF1 + 9,
+	23: keyboard.CodeF1 + 10,
+	24: keyboard.CodeF1 + 11,
+}
+
+// xtermMods converts the modifier parameter of xterm.
+func xtermMods(p int) uint8 {
+	p--
+	var ret uint8
+	if p&1 != 0 {
+		ret |= keyboard.ModShift
+	}
+	if p&2 != 0 {
+		ret |= keyboard.ModAlt
+	}
+	if p&4 != 0 {
+		ret |= keyboard.ModCtrl
+	}
+	if p&8 != 0 {
+		ret |= keyboard.ModMeta
+	}
+	return ret
+}
+
+// parseKey parses the sequence of a special key, like "A" for the up
+// key, "1;5A" for the up key with ctrl, or "5~" for the page up key.
+func parseKey(seq string, in Input) {
+	final := seq[len(seq)-1]
+	params := seq[:len(seq)-1]
+	var v []int
+	if params != "" {
+		var ok bool
+		if v, ok = parseInts(params); !ok {
+			return
+		}
+	}
+	var mods uint8
+	if len(v) >= 2 {
+		mods = xtermMods(v[1])
+	}
+
+	code, found := seqKeys[final]
+	if final == '~' && len(v) > 0 {
+		code, found = tildeKeys[v[0]]
+	}
+	if found {
+		in.Key(code, mods)
+	}
+}
+
+// key sends the key press of an ASCII byte.
+func key(b byte, mods uint8, in Input) {
+	switch {
+	case b == '\r' || b == '\n':
+		in.Key(keyboard.CodeEnter, mods)
+	case b == '\t' || b == keyboard.CodeEscape:
+		in.Key(uint16(b), mods)
+	case b == keyboard.CodeDelete || b == keyboard.CodeBackspace:
+		in.Key(keyboard.CodeBackspace, mods)
+	case b == 0:
+		in.Key(keyboard.CodeSpace, mods|keyboard.ModCtrl)
+	case b < ' ':
+		in.Key(uint16('a'+b-1), mods|keyboard.ModCtrl)
+	case b >= 'A' && b <= 'Z':
+		in.Key(uint16(b-'A'+'a'), mods|keyboard.ModShift)
+	default:
+		in.Key(uint16(b), mods)
+	}
+}
+
+func (t *TermInput) escape(in Input) error {
+	if t.r.Buffered() == 0 {
+		in.Key(keyboard.CodeEscape, 0) // a single escape key
+		return nil
+	}
+	b, err := t.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b != '[' && b != 'O' {
+		key(b, keyboard.ModAlt, in)
+		return nil
+	}
+	seq, err := t.readSeq()
+	if err != nil {
+		return err
+	}
+	if b == '[' && strings.HasPrefix(seq, "<") {
+		parseMouse(seq, in)
+	} else {
+		parseKey(seq, in)
+	}
+	return nil
+}
+
+const keyCtrlC = 3
+
+// Next reads the next input event, and sends it into in. It returns
+// ErrInterrupt when the input is Ctrl-C.
+func (t *TermInput) Next(in Input) error {
+	b, err := t.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b == keyCtrlC {
+		return ErrInterrupt
+	}
+	if b == 0x1b {
+		return t.escape(in)
+	}
+	if b < utf8.RuneSelf {
+		key(b, 0, in)
+		if b >= ' ' && b <= '~' {
+			in.Text(string(b))
+		}
+		return nil
+	}
+
+	// a multi-byte character, which only goes to the text
+	if err := t.r.UnreadByte(); err != nil {
+		return err
+	}
+	r, _, err := t.r.ReadRune()
+	if err != nil {
+		return err
+	}
+	if r != utf8.RuneError {
+		in.Text(string(r))
+	}
+	return nil
+}
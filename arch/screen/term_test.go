@@ -0,0 +1,100 @@
+package screen
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"shanhu.io/smlvm/arch/keyboard"
+)
+
+type testKey struct {
+	code uint16
+	mods uint8
+}
+
+type testInput struct {
+	clicks [][2]uint8
+	keys   []testKey
+	text   string
+}
+
+func (in *testInput) Click(line, col uint8) {
+	in.clicks = append(in.clicks, [2]uint8{line, col})
+}
+
+func (in *testInput) Key(code uint16, mods uint8) {
+	in.keys = append(in.keys, testKey{code, mods})
+}
+
+func (in *testInput) Text(s string) { in.text += s }
+
+func TestTermInput(t *testing.T) {
+	r := NewTermInput(strings.NewReader(
+		"a\x1b[<0;3;2M\x1b[<0;3;2m\x1b[<2;1;1M\x1b[AB" +
+			"\x1b[<0;81;1M\x01\x03\x1b[1;5D\x1b[6~\x1bOQ\x1bx\x7f" +
+			"\xe4\xb8\xad\r\x1b",
+	))
+	in := new(testInput)
+	interrupts := 0
+	for {
+		if err := r.Next(in); err == io.EOF {
+			break
+		} else if err == ErrInterrupt {
+			interrupts++
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if interrupts != 1 {
+		t.Errorf("got %d interrupts, want 1", interrupts)
+	}
+
+	if len(in.clicks) != 1 || in.clicks[0] != [2]uint8{1, 2} {
+		t.Errorf("got clicks %v", in.clicks)
+	}
+	wantKeys := []testKey{
+		{'a', 0},
+		{keyboard.CodeUp, 0},
+		{'b', keyboard.ModShift},
+		{'a', keyboard.ModCtrl},
+		{keyboard.CodeLeft, keyboard.ModCtrl},
+		{keyboard.CodePageDown, 0},
+		{keyboard.CodeF1 + 1, 0},
+		{'x', keyboard.ModAlt},
+		{keyboard.CodeBackspace, 0},
+		{keyboard.CodeEnter, 0},
+		{keyboard.CodeEscape, 0},
+	}
+	if len(in.keys) != len(wantKeys) {
+		t.Fatalf("got keys %v, want %v", in.keys, wantKeys)
+	}
+	for i, k := range wantKeys {
+		if in.keys[i] != k {
+			t.Errorf("key %d: got %v, want %v", i, in.keys[i], k)
+		}
+	}
+	if want := "aB\u4e2d"; in.text != want {
+		t.Errorf("got text %q, want %q", in.text, want)
+	}
+}
+
+func TestTerm(t *testing.T) {
+	buf := new(bytes.Buffer)
+	term := NewTerm(buf, 0)
+	if !term.NeedUpdate() {
+		t.Error("a new terminal should need an update")
+	}
+	term.UpdateColor(map[uint32]byte{Width + 2: 0x1a})
+	term.UpdateText(map[uint32]byte{Width + 2: 'x'})
+	if term.NeedUpdate() {
+		t.Error("should not update right after an update")
+	}
+
+	got := buf.String()
+	want := "\x1b[2;3H\x1b[0;92;41mx"
+	if !strings.Contains(got, want) {
+		t.Errorf("got %q, want it to contain %q", got, want)
+	}
+}
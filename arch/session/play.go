@@ -0,0 +1,106 @@
+package session
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"shanhu.io/smlvm/arch/screen"
+	"shanhu.io/smlvm/arch/table"
+)
+
+// ReadEvents reads all the events of a recorded session.
+func ReadEvents(r io.Reader) ([]*Event, error) {
+	dec := gob.NewDecoder(r)
+	var ret []*Event
+	for {
+		e := new(Event)
+		if err := dec.Decode(e); err == io.EOF {
+			return ret, nil
+		} else if err != nil {
+			return nil, err
+		}
+		ret = append(ret, e)
+	}
+}
+
+// Clicks receives the clicks in a session.
+type Clicks interface {
+	Click(line, col uint8)
+	ClickTable(pos uint8)
+}
+
+// Player plays sessions. The renderers can be nil, in which case their
+// events are skipped.
+type Player struct {
+	Screen screen.Render
+	Table  table.Render
+	Clicks Clicks
+
+	// RealTime waits between the events as long as recorded.
+	RealTime bool
+}
+
+// Send sends an event into the renderers.
+func (p *Player) Send(e *Event) {
+	switch e.Kind {
+	case Text:
+		if p.Screen != nil {
+			p.Screen.UpdateText(e.Cells)
+		}
+	case Color:
+		if p.Screen != nil {
+			p.Screen.UpdateColor(e.Cells)
+		}
+	case Action:
+		if p.Table != nil {
+			p.Table.Act(e.Action)
+		}
+	case Click:
+		if p.Clicks != nil {
+			p.Clicks.Click(e.Line, e.Col)
+		}
+	case TableClick:
+		if p.Clicks != nil {
+			p.Clicks.ClickTable(e.Pos)
+		}
+	}
+}
+
+// Play reads a recorded session and plays it.
+func (p *Player) Play(r io.Reader) error {
+	events, err := ReadEvents(r)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	for _, e := range events {
+		if p.RealTime {
+			time.Sleep(e.Time - time.Since(start))
+		}
+		p.Send(e)
+	}
+	return nil
+}
+
+// Compare compares two sessions read by ReadEvents, for example a
+// session just recorded and a golden one. The times of the events are
+// ignored. It returns an error that describes the first difference.
+func Compare(got, want []*Event) error {
+	for i, e := range want {
+		if i >= len(got) {
+			return fmt.Errorf("event %d missing: want %s", i, e)
+		}
+		g := *got[i]
+		g.Time = e.Time
+		if !reflect.DeepEqual(&g, e) {
+			return fmt.Errorf("event %d: got %s, want %s", i, got[i], e)
+		}
+	}
+	if len(got) > len(want) {
+		return fmt.Errorf("extra event %d: %s", len(want), got[len(want)])
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Package session records the outputs of the screen and the table of a
+// machine, and the clicks sent in, so that they can be played later on
+// any renderer.
+package session
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"time"
+
+	"shanhu.io/smlvm/arch/screen"
+	"shanhu.io/smlvm/arch/table"
+)
+
+// Kinds of the events.
+const (
+	Text       = 1 // screen text update
+	Color      = 2 // screen color update
+	Action     = 3 // table action
+	Click      = 4 // screen click
+	TableClick = 5 // table click
+)
+
+// Event is an event in a session.
+type Event struct {
+	Kind byte
+	Time time.Duration // from the clock of the recorder
+
+	Cells  map[uint32]byte // for Text and Color
+	Action *table.Action   // for Action
+	Line   uint8           // for Click
+	Col    uint8           // for Click
+	Pos    uint8           // for TableClick
+}
+
+func (e *Event) String() string {
+	switch e.Kind {
+	case Text:
+		return fmt.Sprintf("text %v", e.Cells)
+	case Color:
+		return fmt.Sprintf("color %v", e.Cells)
+	case Action:
+		return fmt.Sprintf("action %+v", *e.Action)
+	case Click:
+		return fmt.Sprintf("click %d, %d", e.Line, e.Col)
+	case TableClick:
+		return fmt.Sprintf("table click %d", e.Pos)
+	}
+	return fmt.Sprintf("unknown event %d", e.Kind)
+}
+
+// Recorder records a session. It is a screen renderer and a table
+// renderer, which forwards the updates to the renderers that it wraps.
+type Recorder struct {
+	enc    *gob.Encoder
+	now    func() time.Duration
+	screen screen.Render
+	table  table.Render
+	err    error
+
+	// updates held for the wrapped screen renderer
+	text  map[uint32]byte
+	color map[uint32]byte
+}
+
+// NewRecorder creates a recorder that writes the events into w. s and
+// t are the renderers to wrap, and can be nil. now stamps the events
+// with their times. A machine uses its virtual time, so that the same
+// run records the same session.
+func NewRecorder(
+	w io.Writer, s screen.Render, t table.Render, now func() time.Duration,
+) *Recorder {
+	return &Recorder{
+		enc:    gob.NewEncoder(w),
+		now:    now,
+		screen: s,
+		table:  t,
+	}
+}
+
+func (r *Recorder) record(e *Event) {
+	if r.err != nil {
+		return
+	}
+	e.Time = r.now()
+	r.err = r.enc.Encode(e)
+}
+
+// Err returns the first error of writing the events.
+func (r *Recorder) Err() error { return r.err }
+
+// NeedUpdate always returns true, so that the updates are recorded as
+// soon as they happen, rather than when a wrapped renderer that
+// throttles on the wall clock asks for them. The updates are held for
+// the wrapped screen renderer, and are forwarded here when it needs an
+// update.
+func (r *Recorder) NeedUpdate() bool {
+	if r.screen != nil && r.screen.NeedUpdate() {
+		if len(r.text) > 0 {
+			r.screen.UpdateText(r.text)
+			r.text = nil
+		}
+		if len(r.color) > 0 {
+			r.screen.UpdateColor(r.color)
+			r.color = nil
+		}
+	}
+	return true
+}
+
+func hold(held, m map[uint32]byte) map[uint32]byte {
+	if held == nil {
+		held = make(map[uint32]byte)
+	}
+	for k, v := range m {
+		held[k] = v
+	}
+	return held
+}
+
+// UpdateText records a text update, and holds it for the wrapped
+// screen renderer.
+func (r *Recorder) UpdateText(m map[uint32]byte) {
+	r.record(&Event{Kind: Text, Cells: m})
+	if r.screen != nil {
+		r.text = hold(r.text, m)
+	}
+}
+
+// UpdateColor records a color update, and holds it for the wrapped
+// screen renderer.
+func (r *Recorder) UpdateColor(m map[uint32]byte) {
+	r.record(&Event{Kind: Color, Cells: m})
+	if r.screen != nil {
+		r.color = hold(r.color, m)
+	}
+}
+
+// Act records and forwards a table action.
+func (r *Recorder) Act(a *table.Action) {
+	r.record(&Event{Kind: Action, Action: a})
+	if r.table != nil {
+		r.table.Act(a)
+	}
+}
+
+// Click records a click on the screen.
+func (r *Recorder) Click(line, col uint8) {
+	r.record(&Event{Kind: Click, Line: line, Col: col})
+}
+
+// ClickTable records a click on the table.
+func (r *Recorder) ClickTable(pos uint8) {
+	r.record(&Event{Kind: TableClick, Pos: pos})
+}
@@ -0,0 +1,136 @@
+package session
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"shanhu.io/smlvm/arch/table"
+)
+
+type testRender struct {
+	text    []map[uint32]byte
+	color   []map[uint32]byte
+	actions []*table.Action
+	clicks  []uint8
+	ready   bool // if the screen needs an update
+}
+
+func (r *testRender) NeedUpdate() bool { return r.ready }
+
+func (r *testRender) UpdateText(m map[uint32]byte) {
+	r.text = append(r.text, m)
+}
+
+func (r *testRender) UpdateColor(m map[uint32]byte) {
+	r.color = append(r.color, m)
+}
+
+func (r *testRender) Act(a *table.Action) {
+	r.actions = append(r.actions, a)
+}
+
+func (r *testRender) Click(line, col uint8) {
+	r.clicks = append(r.clicks, line, col)
+}
+
+func (r *testRender) ClickTable(pos uint8) {
+	r.clicks = append(r.clicks, pos)
+}
+
+func record(t *testing.T, inner *testRender) (*Recorder, []byte) {
+	buf := new(bytes.Buffer)
+	var now time.Duration
+	clock := func() time.Duration {
+		now += time.Millisecond
+		return now
+	}
+	rec := NewRecorder(buf, inner, inner, clock)
+	rec.UpdateText(map[uint32]byte{3: 'a'})
+	rec.Click(1, 2)
+	rec.UpdateColor(map[uint32]byte{3: 0x12})
+	rec.Act(&table.Action{Action: "show", Pos: 4})
+	rec.ClickTable(4)
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return rec, buf.Bytes()
+}
+
+func TestSession(t *testing.T) {
+	inner := new(testRender)
+	rec, bs := record(t, inner)
+	if len(inner.actions) != 1 {
+		t.Error("action not forwarded")
+	}
+
+	// screen updates are recorded at once, but wait for the wrapped
+	// screen to need them
+	if len(inner.text) != 0 || len(inner.color) != 0 {
+		t.Error("screen updates forwarded before needed")
+	}
+	rec.UpdateText(map[uint32]byte{4: 'b'})
+	if !rec.NeedUpdate() {
+		t.Error("recorder should always need updates")
+	}
+	inner.ready = true
+	rec.NeedUpdate()
+	if len(inner.text) != 1 || len(inner.text[0]) != 2 ||
+		len(inner.color) != 1 {
+		t.Errorf("got forwarded updates %v and %v",
+			inner.text, inner.color,
+		)
+	}
+
+	out := new(testRender)
+	p := &Player{Screen: out, Table: out, Clicks: out}
+	if err := p.Play(bytes.NewReader(bs)); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.text) != 1 || out.text[0][3] != 'a' {
+		t.Errorf("got text updates %v", out.text)
+	}
+	if len(out.color) != 1 || out.color[0][3] != 0x12 {
+		t.Errorf("got color updates %v", out.color)
+	}
+	if len(out.actions) != 1 || *out.actions[0] != (table.Action{
+		Action: "show", Pos: 4,
+	}) {
+		t.Errorf("got actions %v", out.actions)
+	}
+	if !bytes.Equal(out.clicks, []byte{1, 2, 4}) {
+		t.Errorf("got clicks %v", out.clicks)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	_, bs := record(t, new(testRender))
+	want, err := ReadEvents(bytes.NewReader(bs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, bs = record(t, new(testRender))
+	got, err := ReadEvents(bytes.NewReader(bs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Compare(got, want); err != nil {
+		t.Error(err)
+	}
+	for i, e := range got {
+		if d := time.Duration(i+1) * time.Millisecond; e.Time != d {
+			t.Errorf("event %d at %s, want %s", i, e.Time, d)
+		}
+	}
+
+	got[1].Col = 3
+	if Compare(got, want) == nil {
+		t.Error("should differ on the click")
+	}
+	if Compare(got[:2], want) == nil {
+		t.Error("should differ on the missing events")
+	}
+	if Compare(want, want[:4]) == nil {
+		t.Error("should differ on the extra event")
+	}
+}
@@ -0,0 +1,49 @@
+package arch
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"shanhu.io/smlvm/arch/session"
+	asminst "shanhu.io/smlvm/asm/inst"
+)
+
+func TestSessionRecord(t *testing.T) {
+	buf := new(bytes.Buffer)
+	m := newTestMachineConfig(t, &Config{Session: buf}, []uint32{
+		asminst.Jmp(J, -1), // 8000: j 8000
+	})
+	req := []byte{0, 'x', 1, 2} // text 'x' at line 1, col 2
+	_, code, e := m.calls.call(1, serviceScreen, req, 0)
+	if code != 0 || e != nil {
+		t.Fatalf("screen update failed: code=%d, e=%v", code, e)
+	}
+	m.Click(3, 4)
+	m.Run(2)
+	m.ClickTable(5)
+	m.Run(1)
+	if err := m.SessionErr(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := session.ReadEvents(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*session.Event{
+		{Kind: session.Click, Line: 3, Col: 4},
+		{Kind: session.Text, Cells: map[uint32]byte{82: 'x'}},
+		{Kind: session.TableClick, Pos: 5},
+	}
+	if err := session.Compare(got, want); err != nil {
+		t.Fatal(err)
+	}
+
+	// events are stamped with the virtual time
+	for i, d := range []time.Duration{0, 1, 2} {
+		if want := d * DefaultTickTime; got[i].Time != want {
+			t.Errorf("event %d at %s, want %s", i, got[i].Time, want)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package arch
+
+import (
+	"encoding/gob"
+	"io"
+	"sort"
+	"time"
+
+	"shanhu.io/smlvm/arch/display"
+	"shanhu.io/smlvm/image"
+)
+
+// snapshotVersion is the version of the snapshot format.
+const snapshotVersion = 1
+
+type cpuSnap struct {
+	Regs     []uint32
+	Ring     byte
+	Ncycle   uint64
+	Sleeping bool
+	Halted   bool
+	PTRoot   uint32 // 0 for direct mapping
+}
+
+type callsMessageSnap struct {
+	Service uint32
+	P       []byte
+}
+
+type callsSnap struct {
+	Queue      []*callsMessageSnap
+	TimedSleep bool
+	Sleep      time.Duration
+	Muted      []uint32 // services that are disabled
+}
+
+type tickerSnap struct {
+	NextTick int32
+	Interval int32
+	Noise    int32
+	Code     byte
+	Seed     int64
+	Draws    uint64 // draws from the noise source
+}
+
+type randSnap struct {
+	Seed int64
+	Read uint64 // bytes read from the random stream
+}
+
+type timerEntrySnap struct {
+	Flags  byte
+	Code   byte
+	Period uint64
+	Left   uint64
+}
+
+type timerSnap struct {
+	TickTime time.Duration
+	Now      uint64
+	Timers   []*timerEntrySnap
+}
+
+type consoleSnap struct {
+	Core   byte
+	IntIn  byte
+	IntOut byte
+}
+
+type romSnap struct {
+	Root      string
+	State     byte
+	CountDown int
+	Addr      uint32
+	Bs        []byte
+	Err       byte
+	Core      byte
+	IntDone   byte
+}
+
+type diskSnap struct {
+	Path      string
+	State     byte
+	CountDown int
+	Cmd       byte
+	Block     uint32
+	Count     uint32
+	Addr      uint32
+	Err       byte
+	Core      byte
+	IntDone   byte
+}
+
+type keyboardSnap struct {
+	Mode byte
+}
+
+// snapshot is the serialized state of a machine.
+type snapshot struct {
+	Version int
+
+	MemSize uint32
+	Pages   map[uint32][]uint32
+	Cores   []*cpuSnap
+
+	LastHalt int // the core that halted last, -1 for none
+	LastStop int // the core that issued the last CORESTOP, -1 for none
+
+	Calls   *callsSnap
+	Ticker  *tickerSnap
+	Timer   *timerSnap
+	Console *consoleSnap
+	ROM     *romSnap
+	Disk    *diskSnap
+	Display *display.State
+	Keys    *keyboardSnap
+	Rand    *randSnap
+
+	LegacyDivZero bool
+
+	Sections []*image.Section
+}
+
+func (c *cpu) snap() *cpuSnap {
+	ret := &cpuSnap{
+		Regs:     make([]uint32, Nreg),
+		Ring:     c.ring,
+		Ncycle:   c.ncycle,
+		Sleeping: c.sleeping,
+		Halted:   c.halted,
+	}
+	copy(ret.Regs, c.regs)
+	if c.virtMem.ptable != nil {
+		ret.PTRoot = c.virtMem.ptable.root
+	}
+	return ret
+}
+
+func (c *calls) snap() *callsSnap {
+	ret := &callsSnap{
+		TimedSleep: c.timedSleep,
+		Sleep:      c.sleep,
+	}
+	for e := c.queue.Front(); e != nil; e = e.Next() {
+		m := e.Value.(*callsMessage)
+		ret.Queue = append(ret.Queue, &callsMessageSnap{
+			Service: m.service,
+			P:       m.p,
+		})
+	}
+	for id, enabled := range c.enabled {
+		if !enabled {
+			ret.Muted = append(ret.Muted, id)
+		}
+	}
+	sort.Sort(uint32s(ret.Muted))
+	return ret
+}
+
+func (t *timer) snap() *timerSnap {
+	ret := &timerSnap{
+		TickTime: t.tickTime,
+		Now:      t.now,
+	}
+	for _, e := range t.timers {
+		ret.Timers = append(ret.Timers, &timerEntrySnap{
+			Flags:  e.flags,
+			Code:   e.code,
+			Period: e.period,
+			Left:   e.left,
+		})
+	}
+	return ret
+}
+
+func (r *rom) snap() *romSnap {
+	return &romSnap{
+		Root:      r.root,
+		State:     r.state,
+		CountDown: r.countDown,
+		Addr:      r.addr,
+		Bs:        r.bs,
+		Err:       r.err,
+		Core:      r.Core,
+		IntDone:   r.IntDone,
+	}
+}
+
+func (d *disk) snap() *diskSnap {
+	return &diskSnap{
+		Path:      d.path,
+		State:     d.state,
+		CountDown: d.countDown,
+		Cmd:       d.cmd,
+		Block:     d.block,
+		Count:     d.count,
+		Addr:      d.addr,
+		Err:       d.err,
+		Core:      d.Core,
+		IntDone:   d.IntDone,
+	}
+}
+
+// Snapshot writes the full state of the machine into w, so that it can
+// be restored later with RestoreMachine. Host bindings, such as the
+// console output and the screen, are not saved.
+// The random sources are saved as their seeds and the number of draws,
+// so the restored machines proceed the same way as the machine does.
+func (m *Machine) Snapshot(w io.Writer) error {
+	randSeed, randRead := m.rand.State()
+	s := &snapshot{
+		Version: snapshotVersion,
+		MemSize: m.phyMem.npage * PageSize,
+		Pages:   make(map[uint32][]uint32),
+		Calls:   m.calls.snap(),
+		Ticker: &tickerSnap{
+			NextTick: m.ticker.nextTick,
+			Interval: m.ticker.Interval,
+			Noise:    m.ticker.Noise,
+			Code:     m.ticker.Code,
+			Seed:     m.ticker.src.seed,
+			Draws:    m.ticker.src.draws,
+		},
+		Timer: m.timer.snap(),
+		Console: &consoleSnap{
+			Core:   m.console.Core,
+			IntIn:  m.console.IntIn,
+			IntOut: m.console.IntOut,
+		},
+		Rand:     &randSnap{Seed: randSeed, Read: randRead},
+		Sections: m.Sections,
+
+		LastHalt: m.cores.lastHalt,
+		LastStop: m.cores.lastStop,
+
+		LegacyDivZero: m.legacyDivZero,
+	}
+	for pn, p := range m.phyMem.pages {
+		s.Pages[pn] = p.uints
+	}
+	for _, c := range m.cores.cores {
+		s.Cores = append(s.Cores, c.snap())
+	}
+	if m.rom != nil {
+		s.ROM = m.rom.snap()
+	}
+	if m.disk != nil {
+		s.Disk = m.disk.snap()
+	}
+	if m.display != nil {
+		s.Display = m.display.State()
+	}
+	if m.keys != nil {
+		s.Keys = &keyboardSnap{Mode: m.keys.Mode()}
+	}
+
+	return gob.NewEncoder(w).Encode(s)
+}
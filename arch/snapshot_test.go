@@ -0,0 +1,112 @@
+package arch
+
+import (
+	"bytes"
+	"testing"
+
+	asminst "shanhu.io/smlvm/asm/inst"
+)
+
+func TestSnapshot(t *testing.T) {
+	insts := []uint32{
+		asminst.Imm(LUI, R2, 0, 1),         // lui r2 1
+		asminst.Imm(ADDI, R3, R0, 100),     // addi r3 r0 100
+		asminst.Imm(ADDI, R1, R1, 3),       // .loop: addi r1 r1 3
+		asminst.Imm(SW, R1, R2, 0),         // sw r1 r2
+		asminst.Imm(ADDI, R3, R3, 0xffff),  // addi r3 r3 -1
+		asminst.Br(BNE, R3, R0, -4),        // bne r3 r0 .loop
+		asminst.Sys(HALT, 0, 0),            // halt
+		asminst.Reg(ADD, R4, R4, R4, 0, 0), // add r4 r4 r4
+	}
+	m := newTestMachine(t, 2, insts)
+	if _, e := m.Run(50); e != nil {
+		t.Fatal(e)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := m.Snapshot(buf); err != nil {
+		t.Fatal(err)
+	}
+	m2, err := RestoreMachine(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, core := range []byte{0, 1} {
+		regs1 := m.DumpRegs(core)
+		regs2 := m2.DumpRegs(core)
+		for i := range regs1 {
+			if regs1[i] != regs2[i] {
+				t.Fatalf("core %d reg %d: %d != %d",
+					core, i, regs1[i], regs2[i],
+				)
+			}
+		}
+	}
+
+	n1, e1 := m.Run(1000)
+	n2, e2 := m2.Run(1000)
+	if n1 != n2 {
+		t.Errorf("ran %d ticks after restore, want %d", n2, n1)
+	}
+	if !IsHalt(e1) || !IsHalt(e2) {
+		t.Fatalf("want halt, got %v and %v", e1, e2)
+	}
+
+	w1, err := m.ReadWord(0, 0x10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w2, err := m2.ReadWord(0, 0x10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w1 != 300 || w2 != w1 {
+		t.Errorf("memory got %d and %d, want 300", w1, w2)
+	}
+}
+
+func TestSnapshotKeepsState(t *testing.T) {
+	insts := []uint32{
+		asminst.Imm(ADDI, R1, R1, 1), // .loop: addi r1 r1 1
+		asminst.Jmp(J, -1),           // j .loop
+	}
+	m := newTestMachine(t, 1, insts)
+	m.ticker.Interval = 10
+	if _, e := m.Run(100); e != nil {
+		t.Fatal(e)
+	}
+	if _, code := m.rand.Handle(nil); code != 0 {
+		t.Fatalf("rand failed with %d", code)
+	}
+
+	draws := m.ticker.src.draws
+	_, read := m.rand.State()
+	buf := new(bytes.Buffer)
+	if err := m.Snapshot(buf); err != nil {
+		t.Fatal(err)
+	}
+	if m.ticker.src.draws != draws {
+		t.Error("snapshot drew from the ticker noise")
+	}
+	if _, n := m.rand.State(); n != read {
+		t.Error("snapshot read from the rand service")
+	}
+
+	m2, err := RestoreMachine(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		r1, _ := m.rand.Handle(nil)
+		r2, _ := m2.rand.Handle(nil)
+		if !bytes.Equal(r1, r2) {
+			t.Errorf("rand got %x after restore, want %x", r2, r1)
+		}
+		n1 := m.ticker.Rand.Int31()
+		n2 := m2.ticker.Rand.Int31()
+		if n1 != n2 {
+			t.Errorf("ticker noise got %d after restore, want %d", n2, n1)
+		}
+	}
+}
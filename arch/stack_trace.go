@@ -84,21 +84,14 @@ func debugSection(secs []*image.Section) *image.Section {
 	return nil
 }
 
-// FprintStack prints the stack trace of a machine from its exception
-// and registers.
-func FprintStack(w io.Writer, m *Machine, excep *CoreExcep) error {
-	sec := debugSection(m.Sections)
-	if sec == nil {
-		return errors.New("debug section not found")
-	}
-
-	t, err := debug.UnmarshalTable(sec.Bytes)
-	if err != nil {
-		return err
-	}
-	funcs := sortTable(t)
-
-	core := byte(excep.Core)
+// walkStack walks the call stack of a core from the innermost frame,
+// and calls visit on each frame until visit returns false. When the
+// innermost frame is not in any function, visit is called with a nil
+// function. It returns the error when the stack cannot be recovered.
+func walkStack(
+	m *Machine, core byte, t *debug.Table, funcs []*funcEntry,
+	visit func(pc uint32, name string, f *debug.Func) bool,
+) error {
 	regs := m.DumpRegs(core)
 	pc := regs[PC]
 	sp := regs[SP]
@@ -111,15 +104,12 @@ func FprintStack(w io.Writer, m *Machine, excep *CoreExcep) error {
 		name, f := findFunc(funcs, pc, t)
 		if f == nil {
 			if level == 1 {
-				_, err := fmt.Fprintf(w, "? pc=%08x\n", pc)
-				return err
+				visit(pc, "", nil)
 			}
 			return nil
 		}
-
-		_, err := fmt.Fprintln(w, f.String(name))
-		if err != nil {
-			return err
+		if !visit(pc, name, f) {
+			return nil
 		}
 
 		if f.Size <= 4 || f.Frame == 0 { // cannot be a normal function
@@ -135,7 +125,6 @@ func FprintStack(w io.Writer, m *Machine, excep *CoreExcep) error {
 
 		retAddr, err := m.ReadWord(core, sp+f.Frame-4)
 		if err != nil {
-			_, err := fmt.Fprintf(w, "! unable to recover: %s\n", err)
 			return err
 		}
 
@@ -143,3 +132,38 @@ func FprintStack(w io.Writer, m *Machine, excep *CoreExcep) error {
 		sp = sp + f.Frame
 	}
 }
+
+// FprintStack prints the stack trace of a machine from its exception
+// and registers.
+func FprintStack(w io.Writer, m *Machine, excep *CoreExcep) error {
+	sec := debugSection(m.Sections)
+	if sec == nil {
+		return errors.New("debug section not found")
+	}
+
+	t, err := debug.UnmarshalTable(sec.Bytes)
+	if err != nil {
+		return err
+	}
+	funcs := sortTable(t)
+
+	var werr error
+	err = walkStack(m, byte(excep.Core), t, funcs,
+		func(pc uint32, name string, f *debug.Func) bool {
+			if f == nil {
+				_, werr = fmt.Fprintf(w, "? pc=%08x\n", pc)
+				return false
+			}
+			_, werr = fmt.Fprintln(w, f.String(name))
+			return werr == nil
+		},
+	)
+	if werr != nil {
+		return werr
+	}
+	if err != nil {
+		_, err := fmt.Fprintf(w, "! unable to recover: %s\n", err)
+		return err
+	}
+	return nil
+}
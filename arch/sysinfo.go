@@ -2,6 +2,8 @@ package arch
 
 // SYSINFO commands
 const (
-	NCYCLE = iota // The number of cycles.
-	CPUID         // The CPU id of the current core.
+	NCYCLE    = iota // The number of cycles.
+	CPUID            // The CPU id of the current core.
+	CORESTART        // Starts the halted core of index in r2.
+	CORESTOP         // Halts the core of index in r2.
 )
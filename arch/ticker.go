@@ -5,6 +5,27 @@ import (
 	"time"
 )
 
+// randSource is a seeded random source that counts its draws, so that
+// its state can be saved as the seed and the number of draws.
+type randSource struct {
+	rand.Source
+	seed  int64
+	draws uint64
+}
+
+func newRandSource(seed int64, draws uint64) *randSource {
+	ret := &randSource{Source: rand.NewSource(seed), seed: seed}
+	for ret.draws < draws {
+		ret.Int63()
+	}
+	return ret
+}
+
+func (s *randSource) Int63() int64 {
+	s.draws++
+	return s.Source.Int63()
+}
+
 // Ticker is a device that generates time interrupts.
 type ticker struct {
 	intBus   intBus
@@ -14,6 +35,15 @@ type ticker struct {
 	Noise    int32
 	Rand     *rand.Rand
 	Code     byte
+
+	src *randSource
+}
+
+// seed resets the noise source to continue the random stream of seed
+// after the given number of draws.
+func (t *ticker) seed(seed int64, draws uint64) {
+	t.src = newRandSource(seed, draws)
+	t.Rand = rand.New(t.src)
 }
 
 // NewTicker creates a new time interrupt generator.
@@ -23,7 +53,7 @@ func newTicker(bus intBus) *ticker {
 
 	ret.Interval = 2000
 	ret.Noise = 10
-	ret.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	ret.seed(time.Now().UnixNano(), 0)
 	ret.Code = ErrTimer // time interrupt code
 
 	return ret
@@ -0,0 +1,174 @@
+package arch
+
+import (
+	"time"
+
+	"shanhu.io/smlvm/arch/vpc"
+)
+
+// Commands of a timer request. A request starts with the command byte.
+//
+// timerSet arms a timer, and is 16 bytes long:
+//
+//	0: command
+//	1: core
+//	2: timer index on the core
+//	3: flags
+//	4: interrupt code, used with timerInt
+//	8-16: duration in ticks, or in nanoseconds with timerNanos
+//
+// timerCancel disarms a timer, and is 3 bytes long: command, core and
+// timer index. timerNow is a single byte, and returns the virtual time
+// in nanoseconds as a uint64.
+const (
+	timerSet    = 0
+	timerCancel = 1
+	timerNow    = 2
+)
+
+// Flags of timerSet.
+const (
+	timerPeriodic = 0x1 // rearms the timer after it fires
+	timerNanos    = 0x2 // the duration is in virtual nanoseconds
+	timerInt      = 0x4 // issues an interrupt rather than a message
+
+	timerFlags = timerPeriodic | timerNanos | timerInt
+)
+
+// timersPerCore is the number of timers that each core has.
+const timersPerCore = 4
+
+type timerEntry struct {
+	flags  byte
+	code   byte
+	period uint64 // in ticks
+	left   uint64 // ticks before firing, 0 when disarmed
+}
+
+// timer is a service that fires one-shot and periodic timers on ticks.
+// A fired timer either issues an interrupt to its core, or sends a
+// message of two bytes, the core and the timer index.
+type timer struct {
+	intBus   intBus
+	send     vpc.Sender
+	tickTime time.Duration
+
+	now    uint64 // ticks since the machine starts
+	timers []timerEntry
+}
+
+func newTimer(bus intBus, s vpc.Sender, tickTime time.Duration) *timer {
+	n := int(bus.Ncore()) * timersPerCore
+	return &timer{
+		intBus:   bus,
+		send:     s,
+		tickTime: tickTime,
+		timers:   make([]timerEntry, n),
+	}
+}
+
+func (t *timer) entry(core, index byte) *timerEntry {
+	if core >= t.intBus.Ncore() || index >= timersPerCore {
+		return nil
+	}
+	return &t.timers[int(core)*timersPerCore+int(index)]
+}
+
+// ticks converts a duration of virtual nanoseconds into ticks, rounding
+// up so that a timer never fires early.
+func (t *timer) ticks(ns uint64) uint64 {
+	d := uint64(t.tickTime)
+	return (ns + d - 1) / d
+}
+
+// time returns the virtual time since the machine starts.
+func (t *timer) time() time.Duration {
+	return time.Duration(t.now) * t.tickTime
+}
+
+func (t *timer) set(in []byte) int32 {
+	if len(in) != 16 {
+		return vpc.ErrInvalidArg
+	}
+	e := t.entry(in[1], in[2])
+	flags := in[3]
+	d := Endian.Uint64(in[8:16])
+	if e == nil || flags&^timerFlags != 0 || d == 0 {
+		return vpc.ErrInvalidArg
+	}
+	if flags&timerInt != 0 && in[4] == 0 {
+		return vpc.ErrInvalidArg
+	}
+	if flags&timerNanos != 0 {
+		d = t.ticks(d)
+	}
+
+	*e = timerEntry{
+		flags:  flags,
+		code:   in[4],
+		period: d,
+		left:   d,
+	}
+	return 0
+}
+
+// Handle handles a timer request.
+func (t *timer) Handle(in []byte) ([]byte, int32) {
+	if len(in) == 0 {
+		return nil, vpc.ErrInvalidArg
+	}
+
+	switch in[0] {
+	case timerSet:
+		return nil, t.set(in)
+	case timerCancel:
+		if len(in) != 3 {
+			return nil, vpc.ErrInvalidArg
+		}
+		e := t.entry(in[1], in[2])
+		if e == nil {
+			return nil, vpc.ErrInvalidArg
+		}
+		*e = timerEntry{}
+		return nil, 0
+	case timerNow:
+		if len(in) != 1 {
+			return nil, vpc.ErrInvalidArg
+		}
+		ret := make([]byte, 8)
+		Endian.PutUint64(ret, uint64(t.time()))
+		return ret, 0
+	}
+	return nil, vpc.ErrInvalidArg
+}
+
+func (t *timer) fire(i int, e *timerEntry) {
+	core := byte(i / timersPerCore)
+	if e.flags&timerInt != 0 {
+		t.intBus.Interrupt(e.code, core)
+	} else {
+		t.send.Send([]byte{core, byte(i % timersPerCore)})
+	}
+
+	if e.flags&timerPeriodic != 0 {
+		e.left = e.period
+	} else {
+		*e = timerEntry{}
+	}
+}
+
+// Tick counts down the armed timers, and fires the ones that are due,
+// in the order of the cores and the timer indices.
+func (t *timer) Tick() {
+	t.now++
+	for i := range t.timers {
+		e := &t.timers[i]
+		if e.left == 0 {
+			continue
+		}
+		e.left--
+		if e.left == 0 {
+			t.fire(i, e)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package arch
+
+import (
+	"bytes"
+	"testing"
+
+	"shanhu.io/smlvm/arch/vpc"
+)
+
+func timerSetReq(core, index, flags, code byte, d uint64) []byte {
+	ret := make([]byte, 16)
+	ret[0] = timerSet
+	ret[1] = core
+	ret[2] = index
+	ret[3] = flags
+	ret[4] = code
+	Endian.PutUint64(ret[8:], d)
+	return ret
+}
+
+func TestTimer(t *testing.T) {
+	m := NewMachine(&Config{Ncore: 2, TickTime: 10})
+	tm := m.timer
+	m.ticker.Interval = 1 << 30 // keep the time interrupts out
+
+	for _, req := range [][]byte{
+		timerSetReq(1, 0, 0, 0, 3),
+		timerSetReq(0, 2, timerPeriodic|timerNanos, 0, 25),
+		timerSetReq(1, 3, timerInt, 12, 4),
+	} {
+		if _, code := tm.Handle(req); code != 0 {
+			t.Fatalf("set %v got code %d", req, code)
+		}
+	}
+
+	var got [][]byte
+	for i := 0; i < 9; i++ {
+		m.tickDevices()
+		for m.calls.queueLen() > 0 {
+			front := m.calls.queue.Front()
+			got = append(got, m.calls.queue.Remove(front).(*callsMessage).p)
+		}
+	}
+	want := [][]byte{{0, 2}, {1, 0}, {0, 2}, {0, 2}}
+	if len(got) != len(want) {
+		t.Fatalf("got messages %v, want %v", got, want)
+	}
+	for i := range want {
+		if !bytes.Equal(got[i], want[i]) {
+			t.Errorf("message %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+	if !intPendingBit(m.cores.cores[1], 12) {
+		t.Error("interrupt not issued")
+	}
+
+	if _, code := tm.Handle([]byte{timerCancel, 0, 2}); code != 0 {
+		t.Fatalf("cancel got code %d", code)
+	}
+	for i := 0; i < 10; i++ {
+		m.tickDevices()
+	}
+	if n := m.calls.queueLen(); n != 0 {
+		t.Errorf("canceled timer sent %d messages", n)
+	}
+
+	resp, code := tm.Handle([]byte{timerNow})
+	if code != 0 || len(resp) != 8 {
+		t.Fatalf("now got code %d", code)
+	}
+	if now := Endian.Uint64(resp); now != 190 {
+		t.Errorf("now got %d, want 190", now)
+	}
+}
+
+func TestTimerBadRequest(t *testing.T) {
+	m := NewMachine(&Config{Ncore: 1})
+	for _, req := range [][]byte{
+		nil,
+		{9},
+		{timerNow, 0},
+		{timerCancel, 0},
+		{timerCancel, 1, 0},
+		timerSetReq(1, 0, 0, 0, 5),
+		timerSetReq(0, timersPerCore, 0, 0, 5),
+		timerSetReq(0, 0, 0x80, 0, 5),
+		timerSetReq(0, 0, timerInt, 0, 5),
+		timerSetReq(0, 0, 0, 0, 0),
+		timerSetReq(0, 0, 0, 0, 5)[:8],
+	} {
+		if _, code := m.timer.Handle(req); code != vpc.ErrInvalidArg {
+			t.Errorf("request %v got code %d", req, code)
+		}
+	}
+}
+
+func TestTimerSnapshot(t *testing.T) {
+	m := NewMachine(&Config{Ncore: 1})
+	req := timerSetReq(0, 1, timerPeriodic, 0, 5)
+	if _, code := m.timer.Handle(req); code != 0 {
+		t.Fatalf("set got code %d", code)
+	}
+	for i := 0; i < 3; i++ {
+		m.tickDevices()
+	}
+
+	buf := new(bytes.Buffer)
+	if err := m.Snapshot(buf); err != nil {
+		t.Fatal(err)
+	}
+	r, err := RestoreMachine(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.timer.now != 3 || r.timer.tickTime != DefaultTickTime {
+		t.Errorf("timer clock not restored")
+	}
+	if e := r.timer.timers[1]; e != m.timer.timers[1] {
+		t.Errorf("got timer %+v, want %+v", e, m.timer.timers[1])
+	}
+}
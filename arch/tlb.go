@@ -0,0 +1,66 @@
+package arch
+
+const tlbSize = 64
+
+// tlbEntry caches the translation of a virtual page into a physical
+// page.
+type tlbEntry struct {
+	valid bool
+	vpn   uint32
+	p     *page
+	user  bool // accessible in user mode
+	write bool // writable without updating the page table
+
+	code *codePage // predecoded instructions, nil if not fetched yet
+}
+
+// tlb is a direct mapped translation cache of a core. It is flushed
+// when the page table root changes, or when any page that holds page
+// table entries is modified.
+type tlb struct {
+	entries [tlbSize]tlbEntry
+	gen     uint32 // the page table generation of the entries
+}
+
+func (t *tlb) flush() {
+	for i := range t.entries {
+		t.entries[i].valid = false
+	}
+}
+
+// check flushes the entries if page tables have changed since the
+// entries are cached.
+func (t *tlb) check(gen uint32) {
+	if gen != t.gen {
+		t.flush()
+		t.gen = gen
+	}
+}
+
+func (t *tlb) entry(vpn uint32) *tlbEntry {
+	return &t.entries[vpn%tlbSize]
+}
+
+func (t *tlb) lookup(vpn uint32, ring byte, write bool) *page {
+	e := t.entry(vpn)
+	if !e.valid || e.vpn != vpn {
+		return nil
+	}
+	if ring > 0 && !e.user {
+		return nil
+	}
+	if write && !e.write {
+		return nil
+	}
+	return e.p
+}
+
+func (t *tlb) fill(vpn uint32, p *page, user, write bool) {
+	t.entries[vpn%tlbSize] = tlbEntry{
+		valid: true,
+		vpn:   vpn,
+		p:     p,
+		user:  user,
+		write: write,
+	}
+}
@@ -0,0 +1,79 @@
+package arch
+
+import (
+	"testing"
+
+	asminst "shanhu.io/smlvm/asm/inst"
+)
+
+func TestTLB(t *testing.T) {
+	const root = 0x100000
+	const sub = root + PageSize
+	m := NewMachine(&Config{})
+	identityMap(m, root, 64)
+	vm := m.cores.cores[0].virtMem
+	vm.SetTable(root)
+
+	pm := m.phyMem
+	pm.WriteWord(0x10000, 1)
+	pm.WriteWord(0x11000, 2)
+
+	read := func(ring byte, want uint32) {
+		v, e := vm.ReadWord(0x10000, ring)
+		if e != nil {
+			t.Fatalf("unexpected exception: %s", e)
+		}
+		if v != want {
+			t.Errorf("got %d, want %d", v, want)
+		}
+	}
+
+	read(0, 1)
+
+	// remap the page; the cached translation is stale
+	pm.WriteWord(sub+0x10*4, 0x11000|1<<pteValid)
+	read(0, 2)
+
+	// user mode cannot use the translation cached in kernel mode
+	if _, e := vm.ReadWord(0x10000, 1); e == nil || e.Code != ErrPageFault {
+		t.Errorf("want page fault, got %v", e)
+	}
+	pm.WriteWord(root, sub|1<<pteValid|1<<pteUser)
+	pm.WriteWord(sub+0x10*4, 0x11000|1<<pteValid|1<<pteUser)
+	read(1, 2)
+
+	// the page becomes read only after it is written
+	if e := vm.WriteWord(0x10000, 0, 3); e != nil {
+		t.Fatalf("unexpected exception: %s", e)
+	}
+	pte, _ := pm.ReadWord(sub + 0x10*4)
+	pm.WriteWord(sub+0x10*4, pte|1<<pteReadonly)
+	if e := vm.WriteWord(0x10000, 0, 4); e == nil {
+		t.Error("want read only exception")
+	} else if e.Code != ErrPageReadonly {
+		t.Errorf("want read only exception, got %s", e)
+	}
+
+	vm.SetTable(0)
+	read(0, 1)
+}
+
+func TestInstCache(t *testing.T) {
+	m := newTestMachine(t, 1, []uint32{
+		asminst.Imm(ADDI, R1, R1, 1), // 8000: addi r1 r1 1
+	})
+	c := m.cores.cores[0]
+	if e := c.Tick(); e != nil {
+		t.Fatal(e)
+	}
+
+	// rewrites the instruction
+	m.phyMem.WriteWord(InitPC, asminst.Imm(ADDI, R1, R1, 100))
+	c.regs[PC] = InitPC
+	if e := c.Tick(); e != nil {
+		t.Fatal(e)
+	}
+	if c.regs[R1] != 101 {
+		t.Errorf("got r1=%d, want 101", c.regs[R1])
+	}
+}
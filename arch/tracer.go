@@ -0,0 +1,64 @@
+package arch
+
+// TraceEntry describes one tick of a core: either an executed
+// instruction, or an entry into the interrupt handler.
+type TraceEntry struct {
+	Core  int
+	Cycle uint64
+	PC    uint32 // the PC before the tick
+	Inst  uint32 // the instruction word at PC, if readable
+
+	Ring      byte // the ring before the tick
+	RingAfter byte
+
+	Regs      [Nreg]uint32 // registers before the tick
+	RegsAfter [Nreg]uint32
+
+	// Interrupt is true when the core enters the interrupt handler in
+	// the tick, where IntCode is the interrupt code.
+	Interrupt bool
+	IntCode   byte
+
+	Excep *Excep // the exception thrown out of the core, if any
+}
+
+// Tracer receives the trace of every tick of every core.
+type Tracer interface {
+	Trace(e *TraceEntry)
+}
+
+// SetTracer sets the tracer of the machine. t can be nil to stop
+// tracing.
+func (m *Machine) SetTracer(t Tracer) {
+	for _, c := range m.cores.cores {
+		c.tracer = t
+	}
+}
+
+func (c *cpu) traceTick() *Excep {
+	pc := c.regs[PC]
+	e := &TraceEntry{
+		Core: int(c.index),
+		PC:   pc,
+		Ring: c.ring,
+	}
+	copy(e.Regs[:], c.regs)
+	if in, err := c.virtMem.ReadWord(pc, c.ring); err == nil {
+		e.Inst = in
+	}
+
+	c.intEntered = -1
+	excep := c.tick0()
+
+	e.Cycle = c.ncycle
+	e.RingAfter = c.ring
+	copy(e.RegsAfter[:], c.regs)
+	if c.intEntered >= 0 {
+		e.Interrupt = true
+		e.IntCode = byte(c.intEntered)
+	}
+	e.Excep = excep
+	c.tracer.Trace(e)
+
+	return excep
+}
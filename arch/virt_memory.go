@@ -5,6 +5,7 @@ package arch
 type virtMemory struct {
 	phyMem *phyMemory
 	ptable *pageTable
+	tlb    tlb
 }
 
 // NewVirtMemory creates a new virtual address space with no page table.
@@ -23,6 +24,16 @@ func (vm *virtMemory) SetTable(root uint32) {
 	} else {
 		vm.ptable = newPageTable(vm.phyMem, root)
 	}
+	vm.tlb.flush()
+}
+
+// translate translates the address for ring 0 without touching the page
+// table entries.
+func (vm *virtMemory) translate(addr uint32) (uint32, *Excep) {
+	if vm.ptable == nil {
+		return addr, nil
+	}
+	return vm.ptable.Translate(addr, 0)
 }
 
 func (vm *virtMemory) transRead(addr uint32, ring byte) (uint32, *Excep) {
@@ -39,39 +50,125 @@ func (vm *virtMemory) transWrite(addr uint32, ring byte) (uint32, *Excep) {
 	return vm.ptable.TranslateWrite(addr, ring)
 }
 
-// ReadWord reads the byte at the given virtual address.
+// pageFor returns the physical page of a virtual address for reading
+// or writing. It uses the TLB when possible, and fills the TLB after a
+// page table walk.
+func (vm *virtMemory) pageFor(addr uint32, ring byte, write bool) (
+	*page, *Excep,
+) {
+	vpn := addr / PageSize
+	vm.tlb.check(vm.phyMem.tableGeneration())
+	if p := vm.tlb.lookup(vpn, ring, write); p != nil {
+		return p, nil
+	}
+
+	var pa uint32
+	var e *Excep
+	if write {
+		pa, e = vm.transWrite(addr, ring)
+	} else {
+		pa, e = vm.transRead(addr, ring)
+	}
+	if e != nil {
+		return nil, e
+	}
+	p, e := vm.phyMem.pageForByte(pa)
+	if e != nil {
+		return nil, e
+	}
+
+	user, writable := true, true
+	if pt := vm.ptable; pt != nil {
+		vm.phyMem.markTable(pt.pte1Addr)
+		vm.phyMem.markTable(pt.pte2Addr)
+		pte1, pte2 := pt.pte1, pt.pte2
+		user = pte1.testBit(pteUser) && pte2.testBit(pteUser)
+		writable = !pte1.testBit(pteReadonly) &&
+			!pte2.testBit(pteReadonly) &&
+			pte1.testBit(pteDirty) && pte2.testBit(pteDirty)
+	}
+	vm.tlb.check(vm.phyMem.tableGeneration())
+	vm.tlb.fill(vpn, p, user, writable)
+	return p, nil
+}
+
+// fetchEntry returns the TLB entry of a virtual address for fetching
+// instructions.
+func (vm *virtMemory) fetchEntry(addr uint32, ring byte) (
+	*tlbEntry, *Excep,
+) {
+	if _, e := vm.pageFor(addr, ring, false); e != nil {
+		return nil, e
+	}
+	return vm.tlb.entry(addr / PageSize), nil
+}
+
+// ReadWord reads the word at the given virtual address.
 func (vm *virtMemory) ReadWord(addr uint32, ring byte) (uint32, *Excep) {
-	addr, e := vm.transRead(addr, ring)
+	p, e := vm.pageFor(addr, ring, false)
 	if e != nil {
 		return 0, e
 	}
-	return vm.phyMem.ReadWord(addr)
+	if addr%4 != 0 {
+		return 0, errMisalign
+	}
+	return p.ReadWord(addr), nil
 }
 
-// WriteWord writes the byte at the given virtual address.
+// WriteWord writes the word at the given virtual address.
 func (vm *virtMemory) WriteWord(addr uint32, ring byte, v uint32) *Excep {
-	addr, e := vm.transWrite(addr, ring)
+	p, e := vm.pageFor(addr, ring, true)
 	if e != nil {
 		return e
 	}
-	return vm.phyMem.WriteWord(addr, v)
+	if addr%4 != 0 {
+		return errMisalign
+	}
+	p.WriteWord(addr, v)
+	return nil
+}
+
+// ReadHalf reads the halfword at the given virtual address.
+func (vm *virtMemory) ReadHalf(addr uint32, ring byte) (uint16, *Excep) {
+	p, e := vm.pageFor(addr, ring, false)
+	if e != nil {
+		return 0, e
+	}
+	if addr%2 != 0 {
+		return 0, errMisalign
+	}
+	return p.ReadHalf(addr), nil
+}
+
+// WriteHalf writes the halfword at the given virtual address.
+func (vm *virtMemory) WriteHalf(addr uint32, ring byte, v uint16) *Excep {
+	p, e := vm.pageFor(addr, ring, true)
+	if e != nil {
+		return e
+	}
+	if addr%2 != 0 {
+		return errMisalign
+	}
+	p.WriteHalf(addr, v)
+	return nil
 }
 
 // ReadByte reads the byte at the given virtual address.
 func (vm *virtMemory) ReadByte(addr uint32, ring byte) (byte, *Excep) {
-	addr, e := vm.transRead(addr, ring)
+	p, e := vm.pageFor(addr, ring, false)
 	if e != nil {
 		return 0, e
 	}
-	return vm.phyMem.ReadByte(addr)
+	return p.ReadByte(addr), nil
 }
 
 // WriteByte writes a byte at the given virtual address under
 // a certain ring.
 func (vm *virtMemory) WriteByte(addr uint32, ring byte, v byte) *Excep {
-	addr, e := vm.transWrite(addr, ring)
+	p, e := vm.pageFor(addr, ring, true)
 	if e != nil {
 		return e
 	}
-	return vm.phyMem.WriteByte(addr, v)
+	p.WriteByte(addr, v)
+	return nil
 }
@@ -21,6 +21,9 @@ var (
 		"lbu": arch.LBU,
 		"sw":  arch.SW,
 		"sb":  arch.SB,
+		"lh":  arch.LH,
+		"lhu": arch.LHU,
+		"sh":  arch.SH,
 	}
 
 	// op reg reg imm(unsigned)
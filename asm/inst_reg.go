@@ -35,6 +35,13 @@ var (
 		"divu": arch.DIVU,
 		"mod":  arch.MOD,
 		"modu": arch.MODU,
+
+		"cas":     arch.CAS,
+		"amoadd":  arch.AMOADD,
+		"amoswap": arch.AMOSWAP,
+
+		"mulh":  arch.MULH,
+		"mulhu": arch.MULHU,
 	}
 
 	// op reg reg
@@ -48,7 +55,18 @@ var (
 		"fsub": arch.FSUB,
 		"fmul": arch.FMUL,
 		"fdiv": arch.FDIV,
-		"fint": arch.FINT,
+		"feq":  arch.FEQ,
+		"flt":  arch.FLT,
+		"fle":  arch.FLE,
+	}
+
+	// op reg reg
+	opFloat2Map = map[string]uint32{
+		"fint":  arch.FINT,
+		"itof":  arch.ITOF,
+		"fneg":  arch.FNEG,
+		"fabs":  arch.FABS,
+		"fsqrt": arch.FSQRT,
 	}
 )
 
@@ -113,6 +131,10 @@ func resolveInstReg(log lexing.Logger, ops []*lexing.Token) (*inst, bool) {
 			s2 = resolveReg(log, args[2])
 		}
 		isFloat = 1
+	} else if fn, found = opFloat2Map[opName]; found {
+		// op reg reg
+		argCount(2)
+		isFloat = 1
 	} else {
 		return nil, false
 	}
@@ -25,6 +25,7 @@ var (
 	// op reg reg
 	opSys2Map = map[string]uint32{
 		"sysinfo": arch.SYSINFO,
+		"ipi":     arch.IPI,
 	}
 )
 
@@ -14,6 +14,10 @@ type Options struct {
 	RunTests   bool
 	TestCycles int
 
+	// ProfileTests writes a pprof profile of each test run as the
+	// output "<test>.pprof" of the package.
+	ProfileTests bool
+
 	SaveDeps       func(deps *dagvis.Map)
 	SaveFileTokens func(p string, toks []*lexing.Token)
 	LogLine        func(s string)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"sort"
 	"strings"
 
@@ -22,6 +23,7 @@ func cycleStr(n int) string {
 
 func runTests(
 	log lexing.Logger, tests map[string]uint32, img []byte, opt *Options,
+	profOut func(test string) io.WriteCloser,
 ) {
 	logln := func(s string) {
 		if opt.LogLine == nil {
@@ -72,8 +74,13 @@ func runTests(
 
 	for _, test := range testNames {
 		arg := tests[test]
+		var prof *arch.Profiler
+		if profOut != nil {
+			prof = arch.NewProfiler(0)
+		}
 		m := arch.NewMachine(&arch.Config{
-			BootArg: arg,
+			BootArg:  arg,
+			Profiler: prof,
 		})
 		if err := m.LoadImageBytes(img); err != nil {
 			report(test, 0, false, m, err)
@@ -87,6 +94,11 @@ func runTests(
 		} else {
 			err = excep
 		}
+		if prof != nil {
+			w := profOut(test)
+			lexing.LogError(log, prof.WriteProfile(w))
+			lexing.LogError(log, w.Close())
+		}
 		if strings.HasPrefix(test, "TestBad") {
 			report(test, n, arch.IsPanic(err), m, err)
 		} else {
@@ -114,7 +126,13 @@ func runPkgTests(c *context, p *pkg) []*lexing.Error {
 				return es
 			}
 
-			runTests(log, tests, img, c.Options)
+			var profOut func(string) io.WriteCloser
+			if c.ProfileTests {
+				profOut = func(test string) io.WriteCloser {
+					return c.output.Output(p.path, test+".pprof")
+				}
+			}
+			runTests(log, tests, img, c.Options, profOut)
 			if es := log.Errs(); es != nil {
 				return es
 			}
@@ -26,6 +26,9 @@ var (
 	cpuProfile = flag.String("profile", "", "cpu profile output")
 	pkg        = flag.String("pkg", "", "package to build")
 	homeDir    = flag.String("home", ".", "the home directory")
+	vmProfile  = flag.Bool("vmprofile", false,
+		"profile tests on the vm, writing pprof files to the output",
+	)
 )
 
 func checkInitPC() {
@@ -62,6 +65,7 @@ func main() {
 	b.InitPC = uint32(*initPC)
 	b.RunTests = *runTests
 	b.StaticOnly = *staticOnly
+	b.ProfileTests = *vmProfile
 
 	var es []*lexing.Error
 	if *pkg == "" {
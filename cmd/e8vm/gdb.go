@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"shanhu.io/smlvm/arch"
+	"shanhu.io/smlvm/gdb"
+)
+
+type stdio struct{}
+
+func (stdio) Read(p []byte) (int, error)  { return os.Stdin.Read(p) }
+func (stdio) Write(p []byte) (int, error) { return os.Stdout.Write(p) }
+
+func serveGDB(bs []byte, addr string) error {
+	var out io.Writer
+	if addr == "-" {
+		out = os.Stderr // stdout is used by the protocol
+	}
+	m, err := newMachine(bs, out, nil)
+	if err != nil {
+		return err
+	}
+	stub := gdb.NewStub(arch.NewDebugger(m), 0, loadTable(m.Sections))
+	if addr == "-" {
+		err = stub.Serve(stdio{})
+	} else {
+		err = gdb.ListenAndServe(addr, stub)
+	}
+	if symErr := stub.SymbolErr(); symErr != nil {
+		fmt.Fprintln(os.Stderr, "warning:", symErr)
+	}
+	return err
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"shanhu.io/smlvm/arch"
+	"shanhu.io/smlvm/debug"
+	"shanhu.io/smlvm/image"
+)
+
+const interactHelp = `commands:
+  b <addr|func>       add a breakpoint
+  d <addr|func>       delete a breakpoint
+  w <addr> [r|w|rw]   watch a word for reads and/or writes
+  uw <addr>           remove watchpoints at the address
+  c [n]               continue for at most n ticks
+  s [core]            step one instruction
+  n [core]            step over calls
+  r [core]            print registers
+  set <core> <reg> <v> set a register
+  x <addr> [n]        print n words in memory
+  poke <addr> <v>     write a word into memory
+  bt [core]           print the stack trace
+  rec <n>             record the last n ticks for stepping backwards
+  rs                  step back one tick
+  rc                  continue backwards to a breakpoint
+  rw <addr>           step back to the last write of a word
+  rr <reg>            step back to the last write of a register
+  q                   quit`
+
+// maxStepOver limits the number of instructions in a step over.
+const maxStepOver = 1000000
+
+var regNames = []string{"r0", "r1", "r2", "r3", "r4", "sp", "ret", "pc"}
+
+type interact struct {
+	d     *arch.Debugger
+	table *debug.Table
+	out   io.Writer
+	core  byte
+}
+
+func loadTable(secs []*image.Section) *debug.Table {
+	for _, sec := range secs {
+		if sec.Type != image.Debug {
+			continue
+		}
+		t, err := debug.UnmarshalTable(sec.Bytes)
+		if err != nil {
+			return nil
+		}
+		return t
+	}
+	return nil
+}
+
+func parseUint32(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(v), nil
+}
+
+func (in *interact) addr(s string) (uint32, error) {
+	if in.table != nil {
+		if f, found := in.table.Funcs[s]; found {
+			return f.Start, nil
+		}
+	}
+	return parseUint32(s)
+}
+
+func (in *interact) coreArg(args []string, i int) (byte, error) {
+	if len(args) <= i {
+		return in.core, nil
+	}
+	v, err := strconv.ParseUint(args[i], 0, 8)
+	if err != nil {
+		return 0, err
+	}
+	if int(v) >= in.d.Ncore() {
+		return 0, fmt.Errorf("core %d out of range", v)
+	}
+	in.core = byte(v)
+	return in.core, nil
+}
+
+func (in *interact) printRegs(core byte) {
+	regs := in.d.Regs(core)
+	for i, name := range regNames {
+		fmt.Fprintf(in.out, " %3s = 0x%08x %-11d\n",
+			name, regs[i], int32(regs[i]),
+		)
+	}
+	fmt.Fprintf(in.out, "ring = %d\n", in.d.Ring(core))
+}
+
+func (in *interact) printStop(s *arch.Stop) {
+	if s == nil {
+		fmt.Fprintln(in.out, "(ticks run out)")
+		return
+	}
+	fmt.Fprintln(in.out, s)
+	in.core = byte(s.Core)
+	pc := in.d.Regs(in.core)[arch.PC]
+	if w, err := in.d.ReadWord(in.core, pc); err == nil {
+		fmt.Fprintf(in.out, "%08x: %08x\n", pc, w)
+	}
+}
+
+func (in *interact) exec(cmd string, args []string) error {
+	switch cmd {
+	case "b", "d":
+		if len(args) != 1 {
+			return fmt.Errorf("%s needs an address", cmd)
+		}
+		a, err := in.addr(args[0])
+		if err != nil {
+			return err
+		}
+		if cmd == "b" {
+			in.d.AddBreak(a)
+		} else {
+			in.d.RemoveBreak(a)
+		}
+	case "w":
+		if len(args) < 1 {
+			return fmt.Errorf("w needs an address")
+		}
+		a, err := in.addr(args[0])
+		if err != nil {
+			return err
+		}
+		mode := "w"
+		if len(args) > 1 {
+			mode = args[1]
+		}
+		in.d.AddWatch(&arch.Watchpoint{
+			Addr:  a,
+			Size:  4,
+			Read:  strings.Contains(mode, "r"),
+			Write: strings.Contains(mode, "w"),
+		})
+	case "uw":
+		if len(args) != 1 {
+			return fmt.Errorf("uw needs an address")
+		}
+		a, err := in.addr(args[0])
+		if err != nil {
+			return err
+		}
+		in.d.RemoveWatch(a)
+	case "c":
+		n := 0
+		if len(args) > 0 {
+			v, err := strconv.Atoi(args[0])
+			if err != nil {
+				return err
+			}
+			n = v
+		}
+		ticks, s := in.d.Continue(n)
+		fmt.Fprintf(in.out, "(%d ticks)\n", ticks)
+		in.printStop(s)
+	case "s", "n":
+		core, err := in.coreArg(args, 0)
+		if err != nil {
+			return err
+		}
+		if cmd == "s" {
+			in.printStop(in.d.Step(core))
+		} else {
+			in.printStop(in.d.StepOver(core, maxStepOver))
+		}
+	case "r":
+		core, err := in.coreArg(args, 0)
+		if err != nil {
+			return err
+		}
+		in.printRegs(core)
+	default:
+		return in.execMem(cmd, args)
+	}
+	return nil
+}
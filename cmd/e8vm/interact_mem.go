@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"shanhu.io/smlvm/arch"
+)
+
+func regIndex(s string) (int, error) {
+	for i, name := range regNames {
+		if name == s {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid register %q", s)
+}
+
+func (in *interact) execMem(cmd string, args []string) error {
+	switch cmd {
+	case "set":
+		if len(args) != 3 {
+			return fmt.Errorf("set needs a core, a register and a value")
+		}
+		core, err := in.coreArg(args, 0)
+		if err != nil {
+			return err
+		}
+		reg, err := regIndex(args[1])
+		if err != nil {
+			return err
+		}
+		v, err := parseUint32(args[2])
+		if err != nil {
+			return err
+		}
+		in.d.SetReg(core, reg, v)
+	case "x":
+		if len(args) < 1 {
+			return fmt.Errorf("x needs an address")
+		}
+		a, err := in.addr(args[0])
+		if err != nil {
+			return err
+		}
+		n := 1
+		if len(args) > 1 {
+			if n, err = strconv.Atoi(args[1]); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < n; i++ {
+			addr := a + uint32(i)*4
+			w, err := in.d.ReadWord(in.core, addr)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(in.out, "%08x: %08x\n", addr, w)
+		}
+	case "poke":
+		if len(args) != 2 {
+			return fmt.Errorf("poke needs an address and a value")
+		}
+		a, err := in.addr(args[0])
+		if err != nil {
+			return err
+		}
+		v, err := parseUint32(args[1])
+		if err != nil {
+			return err
+		}
+		return in.d.WriteWord(in.core, a, v)
+	case "bt":
+		core, err := in.coreArg(args, 0)
+		if err != nil {
+			return err
+		}
+		e := &arch.CoreExcep{Core: int(core)}
+		return arch.FprintStack(in.out, in.d.Machine(), e)
+	case "h", "help":
+		fmt.Fprintln(in.out, interactHelp)
+	default:
+		return in.execReverse(cmd, args)
+	}
+	return nil
+}
+
+func (in *interact) loop(r io.Reader) {
+	s := bufio.NewScanner(r)
+	for {
+		fmt.Fprint(in.out, "(e8) ")
+		if !s.Scan() {
+			fmt.Fprintln(in.out)
+			return
+		}
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "q" {
+			return
+		}
+		if err := in.exec(fields[0], fields[1:]); err != nil {
+			fmt.Fprintln(in.out, err)
+		}
+	}
+}
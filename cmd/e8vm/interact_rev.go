@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+func (in *interact) execReverse(cmd string, args []string) error {
+	switch cmd {
+	case "rec":
+		if len(args) != 1 {
+			return fmt.Errorf("rec needs the number of ticks")
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+		in.d.Record(n)
+	case "rs":
+		if !in.d.StepBack() {
+			return fmt.Errorf("no recorded history")
+		}
+	case "rc":
+		n, s := in.d.ReverseContinue()
+		fmt.Fprintf(in.out, "(%d ticks back)\n", n)
+		if s == nil {
+			fmt.Fprintln(in.out, "(start of recorded history)")
+			return nil
+		}
+		in.printStop(s)
+	case "rw":
+		if len(args) != 1 {
+			return fmt.Errorf("rw needs an address")
+		}
+		a, err := in.addr(args[0])
+		if err != nil {
+			return err
+		}
+		n, core, found := in.d.ReverseToWrite(in.core, a)
+		if !found {
+			return fmt.Errorf("no recorded write to %08x", a)
+		}
+		fmt.Fprintf(in.out, "(%d ticks back)\n", n)
+		if core < 0 {
+			fmt.Fprintln(in.out, "written by a device")
+			return nil
+		}
+		in.core = byte(core)
+		in.printRegs(in.core)
+	case "rr":
+		if len(args) != 1 {
+			return fmt.Errorf("rr needs a register")
+		}
+		reg, err := regIndex(args[0])
+		if err != nil {
+			return err
+		}
+		n, found := in.d.ReverseToRegWrite(in.core, reg)
+		if !found {
+			return fmt.Errorf("no recorded write to %s", args[0])
+		}
+		fmt.Fprintf(in.out, "(%d ticks back)\n", n)
+		in.printRegs(in.core)
+	default:
+		return fmt.Errorf("unknown command %q, try help", cmd)
+	}
+	return nil
+}
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"math"
 	"os"
 
 	"shanhu.io/smlvm/arch"
+	"shanhu.io/smlvm/arch/screen"
 	"shanhu.io/smlvm/dasm"
 	"shanhu.io/smlvm/debug"
 	"shanhu.io/smlvm/image"
@@ -23,35 +25,161 @@ var (
 	printStatus = flag.Bool("s", false, "print status after execution")
 	bootArg     = flag.Uint("arg", 0, "boot argument, a uint32 number")
 	romRoot     = flag.String("rom", "", "rom root path")
+	diskImage   = flag.String("disk", "", "disk image file of the block device")
 	randSeed    = flag.Int64("seed", 0, "random seed, 0 for using the time")
+	interactive = flag.Bool("i", false, "run in interactive debugging mode")
+	stdin       = flag.Bool("stdin", false, "feed stdin to the console input")
+	ncore       = flag.Int("ncore", 1, "number of cores")
+	parallel    = flag.Bool("parallel", false, "run cores in parallel")
+	legacyDiv   = flag.Bool("legacydiv", false,
+		"make division by zero return 0 instead of faulting",
+	)
+	profileFile = flag.String("profile", "",
+		"write a pprof profile of the vm execution to a file",
+	)
+	gdbAddr = flag.String("gdb", "",
+		"serve gdb remote protocol on a tcp address, or - for stdio",
+	)
+	recordFile = flag.String("record", "",
+		"record the nondeterministic inputs into a file",
+	)
+	replayFile = flag.String("replay", "",
+		"replay the inputs recorded in a file",
+	)
+	screenMode = flag.Bool("screen", false,
+		"run a screen program on the terminal; use -n 0 to run forever",
+	)
+	sessionFile = flag.String("session", "",
+		"record the screen and table outputs and the clicks into a file",
+	)
+	playSession = flag.Bool("play", false,
+		"play the session file of the input on the terminal",
+	)
 )
 
-func run(bs []byte) (int, error) {
+var recordOut, sessionOut *os.File
+
+func inputLogConfig(c *arch.Config) error {
+	if *replayFile != "" {
+		f, err := os.Open(*replayFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		l, err := arch.ReadInputLog(f)
+		if err != nil {
+			return err
+		}
+		c.Replay = l
+	} else if *recordFile != "" {
+		f, err := os.Create(*recordFile)
+		if err != nil {
+			return err
+		}
+		recordOut = f
+		c.Record = f
+	}
+	if *sessionFile != "" {
+		f, err := os.Create(*sessionFile)
+		if err != nil {
+			return err
+		}
+		sessionOut = f
+		c.Session = f
+	}
+	return nil
+}
+
+func closeFile(f *os.File) {
+	if f == nil {
+		return
+	}
+	if err := f.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func closeRecord() {
+	closeFile(recordOut)
+	closeFile(sessionOut)
+	recordOut, sessionOut = nil, nil
+}
+
+// logRecordErrs logs the errors of recording the inputs and the
+// session.
+func logRecordErrs(m *arch.Machine) {
+	if err := m.InputLogErr(); err != nil {
+		log.Print(err)
+	}
+	if err := m.SessionErr(); err != nil {
+		log.Print(err)
+	}
+}
+
+func newMachine(bs []byte, out io.Writer, s screen.Render) (
+	*arch.Machine, error,
+) {
 	if *bootArg > math.MaxUint32 {
 		log.Fatalf("boot arg(%d) is too large", *bootArg)
 	}
 
-	// create a single core machine
-	m := arch.NewMachine(&arch.Config{
+	c := &arch.Config{
 		MemSize:  uint32(*memSize),
+		Ncore:    *ncore,
+		Parallel: *parallel,
 		ROM:      *romRoot,
+		Disk:     *diskImage,
 		RandSeed: *randSeed,
 		BootArg:  uint32(*bootArg),
-	})
+		Output:   out,
+		Screen:   s,
+
+		LegacyDivZero: *legacyDiv,
+	}
+	if err := inputLogConfig(c); err != nil {
+		return nil, err
+	}
+	m := arch.NewMachine(c)
 
 	secs, err := image.Read(bytes.NewReader(bs))
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	if err := m.LoadSections(secs); err != nil {
+		return nil, err
+	}
+	if err := setupTrace(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func run(bs []byte) (int, error) {
+	m, err := newMachine(bs, nil, nil)
+	if err != nil {
 		return 0, err
 	}
+	if *stdin {
+		m.SetInput(os.Stdin)
+	}
+
+	var prof *arch.Profiler
+	if *profileFile != "" {
+		prof = arch.NewProfiler(0)
+		m.SetProfiler(prof)
+	}
 
 	ret, exp := m.Run(*ncycle)
+	if prof != nil {
+		if err := writeProfile(prof, *profileFile); err != nil {
+			log.Fatal(err)
+		}
+	}
 	if *printStatus {
 		m.PrintCoreStatus()
 	}
+	logRecordErrs(m)
 
 	if !arch.IsHalt(exp) {
 		fmt.Println(exp)
@@ -67,6 +195,18 @@ func run(bs []byte) (int, error) {
 	return ret, exp
 }
 
+func writeProfile(p *arch.Profiler, fname string) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	if err := p.WriteProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 func main() {
 	flag.Parse()
 
@@ -76,8 +216,14 @@ func main() {
 	}
 
 	fname := args[0]
+	defer closeTrace()
+	defer closeRecord()
 
-	if *doDasm {
+	if *playSession {
+		if err := playTerm(fname); err != nil {
+			log.Fatal(err)
+		}
+	} else if *doDasm {
 		f, err := os.Open(fname)
 		defer f.Close()
 
@@ -111,7 +257,31 @@ func main() {
 		if err != nil {
 			log.Fatal(err)
 		}
-		n, e := run(bs)
+		if *gdbAddr != "" {
+			if err := serveGDB(bs, *gdbAddr); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		if *interactive {
+			m, err := newMachine(bs, nil, nil)
+			if err != nil {
+				log.Fatal(err)
+			}
+			in := &interact{
+				d:     arch.NewDebugger(m),
+				table: loadTable(m.Sections),
+				out:   os.Stdout,
+			}
+			in.loop(os.Stdin)
+			return
+		}
+
+		runFunc := run
+		if *screenMode {
+			runFunc = runScreen
+		}
+		n, e := runFunc(bs)
 		fmt.Printf("(%d cycles)\n", n)
 		if e != nil {
 			if !arch.IsHalt(e) {
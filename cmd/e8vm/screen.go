@@ -0,0 +1,176 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"time"
+
+	"shanhu.io/smlvm/arch"
+	"shanhu.io/smlvm/arch/screen"
+	"shanhu.io/smlvm/arch/session"
+)
+
+// screenSlice is the number of cycles to run between two checks of the
+// terminal input.
+const screenSlice = 10000
+
+type termEvent struct {
+	quit      bool
+	click     bool
+	line, col uint8
+	code      uint16 // key code, 0 for text
+	mods      uint8
+	text      string
+}
+
+// termEvents sends the terminal input events into a channel, so that
+// they are sent into the machine between the runs.
+type termEvents chan *termEvent
+
+func (c termEvents) Click(line, col uint8) {
+	c <- &termEvent{click: true, line: line, col: col}
+}
+
+func (c termEvents) Key(code uint16, mods uint8) {
+	c <- &termEvent{code: code, mods: mods}
+}
+
+func (c termEvents) Text(s string) { c <- &termEvent{text: s} }
+
+func readTerm(events termEvents) {
+	in := screen.NewTermInput(os.Stdin)
+	for {
+		err := in.Next(events)
+		if err == screen.ErrInterrupt {
+			events <- &termEvent{quit: true}
+		}
+		if err != nil {
+			break
+		}
+	}
+	close(events)
+}
+
+func setRawTerm(raw bool) error {
+	args := []string{"sane"}
+	if raw {
+		args = []string{"raw", "-echo"}
+	}
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
+
+// send sends an event into the machine. It returns false when the user
+// quits with Ctrl-C.
+func send(m *arch.Machine, e *termEvent) bool {
+	if e.quit {
+		return false
+	}
+	if e.click {
+		m.Click(e.line, e.col)
+		return true
+	}
+	if e.code == 0 {
+		m.TypeText(e.text)
+		return true
+	}
+	// terminals only send the presses
+	m.KeyPress(e.code, e.mods, true)
+	m.KeyPress(e.code, e.mods, false)
+	return true
+}
+
+// wait waits for an event when the machine is sleeping. It returns
+// false when there will be no more events.
+func wait(m *arch.Machine, events termEvents) (*termEvent, bool) {
+	if m.HasPending() {
+		return nil, true
+	}
+	d, timed := m.SleepTime()
+	if !timed {
+		if events == nil {
+			return nil, false
+		}
+		e, ok := <-events
+		return e, ok
+	}
+	select {
+	case e, ok := <-events:
+		return e, ok
+	case <-time.After(d):
+		return nil, true
+	}
+}
+
+// runScreen runs the program with the screen rendered on the terminal,
+// until the program exits, or the user presses Ctrl-C.
+func runScreen(bs []byte) (int, error) {
+	term := screen.NewTerm(os.Stdout, 0)
+	m, err := newMachine(bs, os.Stderr, term)
+	if err != nil {
+		return 0, err
+	}
+
+	defer logRecordErrs(m) // after restoring the terminal
+
+	if err := setRawTerm(true); err != nil {
+		return 0, err
+	}
+	defer setRawTerm(false)
+	term.Start()
+	defer term.Stop()
+
+	events := make(termEvents, 64)
+	go readTerm(events)
+
+	n := 0
+	for *ncycle == 0 || n < *ncycle {
+		slice := screenSlice
+		if *ncycle > 0 && *ncycle-n < slice {
+			slice = *ncycle - n
+		}
+		k, exp := m.Run(slice)
+		n += k
+		if exp != nil && !arch.IsSleep(exp) {
+			return n, exp
+		}
+		if exp != nil {
+			e, ok := wait(m, events)
+			if !ok && events == nil {
+				return n, nil // sleeping forever
+			}
+			if !ok {
+				events = nil // input closed; only wait for timeouts
+			} else if e != nil && !send(m, e) {
+				return n, nil
+			}
+		}
+
+		for len(events) > 0 {
+			if !send(m, <-events) {
+				return n, nil
+			}
+		}
+	}
+	return n, nil
+}
+
+// playTerm plays a recorded session on the terminal in real time.
+func playTerm(fname string) error {
+	f, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	term := screen.NewTerm(os.Stdout, 0)
+	term.Start()
+	defer term.Stop()
+	p := &session.Player{Screen: term, RealTime: true}
+	if err := p.Play(f); err != nil {
+		return err
+	}
+	time.Sleep(time.Second) // shows the last frame for a while
+	return nil
+}
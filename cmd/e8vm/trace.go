@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"shanhu.io/smlvm/arch"
+	"shanhu.io/smlvm/trace"
+)
+
+var (
+	traceFile  = flag.String("trace", "", "write the execution trace to a file")
+	traceFuncs = flag.String("trace_func", "",
+		"trace only in the functions, comma separated, like main.main",
+	)
+	traceCores = flag.String("trace_core", "",
+		"trace only on the cores, comma separated",
+	)
+	traceRing = flag.Int("trace_ring", -1,
+		"trace only the ticks in or entering/leaving the ring; -1 for all",
+	)
+	tracePC = flag.String("trace_pc", "",
+		"trace only the pc range, in the form of start:end",
+	)
+)
+
+type traceOut struct {
+	f *os.File
+	w *bufio.Writer
+}
+
+var tracing *traceOut
+
+func (t *traceOut) close() error {
+	if err := t.w.Flush(); err != nil {
+		t.f.Close()
+		return err
+	}
+	return t.f.Close()
+}
+
+func parseTraceFilter() (*trace.Filter, error) {
+	f := trace.NewFilter()
+	f.Ring = *traceRing
+
+	if *tracePC != "" {
+		parts := strings.SplitN(*tracePC, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid pc range %q", *tracePC)
+		}
+		var err error
+		if f.PCStart, err = parseUint32(parts[0]); err != nil {
+			return nil, err
+		}
+		if f.PCEnd, err = parseUint32(parts[1]); err != nil {
+			return nil, err
+		}
+	}
+	if *traceFuncs != "" {
+		f.Funcs = strings.Split(*traceFuncs, ",")
+	}
+	if *traceCores != "" {
+		for _, s := range strings.Split(*traceCores, ",") {
+			c, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, err
+			}
+			f.Cores = append(f.Cores, c)
+		}
+	}
+	return f, nil
+}
+
+// setupTrace starts tracing the machine if the trace flag is set.
+func setupTrace(m *arch.Machine) error {
+	if *traceFile == "" {
+		return nil
+	}
+
+	filter, err := parseTraceFilter()
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(*traceFile)
+	if err != nil {
+		return err
+	}
+
+	tracing = &traceOut{f: f, w: bufio.NewWriter(f)}
+	t := loadTable(m.Sections)
+	m.SetTracer(trace.NewWriter(tracing.w, t, filter))
+	return nil
+}
+
+func closeTrace() {
+	if tracing == nil {
+		return
+	}
+	if err := tracing.close(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	tracing = nil
+}
@@ -17,6 +17,9 @@ var (
 	homeDir  = flag.String("home", ".", "the home directory")
 	plan     = flag.Bool("plan", false, "plan only")
 	std      = flag.String("std", "", "standard library directory")
+	profile  = flag.Bool("profile", false,
+		"profile tests on the vm, writing pprof files to the output",
+	)
 )
 
 func handleErrs(errs []*lexing.Error) {
@@ -37,6 +40,7 @@ func main() {
 	b.Verbose = true
 	b.InitPC = arch.InitPC
 	b.RunTests = *runTests
+	b.ProfileTests = *profile
 
 	pkgs, err := builds.SelectPkgs(home, *pkg)
 	if err != nil {
@@ -3,6 +3,7 @@ package coder
 import (
 	"bytes"
 	"encoding/binary"
+	"io"
 )
 
 // Decoder is a simple binary decoder
@@ -31,7 +32,7 @@ func (c *Decoder) U8() byte {
 // U32 reads a word out of the decoder.
 func (c *Decoder) U32() uint32 {
 	var buf [4]byte
-	if _, err := c.r.Read(buf[:]); err != nil {
+	if _, err := io.ReadFull(c.r, buf[:]); err != nil {
 		c.Err = err
 		return 0
 	}
@@ -41,7 +42,7 @@ func (c *Decoder) U32() uint32 {
 // Bytes reads some raw bytes out of the decoder.
 func (c *Decoder) Bytes(n int) []byte {
 	buf := make([]byte, n)
-	if _, err := c.r.Read(buf); err != nil {
+	if _, err := io.ReadFull(c.r, buf); err != nil {
 		c.Err = err
 		return nil
 	}
@@ -28,3 +28,8 @@ func (c *Encoder) U32(w uint32) {
 	binary.LittleEndian.PutUint32(buf[:], w)
 	c.buf.Write(buf[:])
 }
+
+// Bytes returns the bytes encoded.
+func (c *Encoder) Bytes() []byte {
+	return c.buf.Bytes()
+}
@@ -18,6 +18,9 @@ var (
 		arch.LBU: "lbu",
 		arch.SW:  "sw",
 		arch.SB:  "sb",
+		arch.LH:  "lh",
+		arch.LHU: "lhu",
+		arch.SH:  "sh",
 	}
 
 	opImuMap = map[uint32]string{
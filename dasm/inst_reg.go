@@ -31,6 +31,13 @@ var (
 		arch.DIVU: "divu",
 		arch.MOD:  "mod",
 		arch.MODU: "modu",
+
+		arch.CAS:     "cas",
+		arch.AMOADD:  "amoadd",
+		arch.AMOSWAP: "amoswap",
+
+		arch.MULH:  "mulh",
+		arch.MULHU: "mulhu",
 	}
 
 	opFloatMap = map[uint32]string{
@@ -38,7 +45,17 @@ var (
 		arch.FSUB: "fsub",
 		arch.FMUL: "fmul",
 		arch.FDIV: "fdiv",
-		arch.FINT: "fint",
+		arch.FEQ:  "feq",
+		arch.FLT:  "flt",
+		arch.FLE:  "fle",
+	}
+
+	opFloat2Map = map[uint32]string{
+		arch.FINT:  "fint",
+		arch.ITOF:  "itof",
+		arch.FNEG:  "fneg",
+		arch.FABS:  "fabs",
+		arch.FSQRT: "fsqrt",
 	}
 )
 
@@ -68,6 +85,8 @@ func instReg(addr uint32, in uint32) *Line {
 	} else {
 		if opStr, found := opFloatMap[funct]; found {
 			s = fmt.Sprintf("%s %s %s %s", opStr, dest, src1, src2)
+		} else if opStr, found := opFloat2Map[funct]; found {
+			s = fmt.Sprintf("%s %s %s", opStr, dest, src1)
 		}
 	}
 
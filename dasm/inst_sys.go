@@ -22,6 +22,7 @@ var (
 
 	opSys2Map = map[uint32]string{
 		arch.SYSINFO: "sysinfo",
+		arch.IPI:     "ipi",
 	}
 )
 
@@ -0,0 +1,59 @@
+package debug
+
+import (
+	"fmt"
+	"sort"
+)
+
+type indexEntry struct {
+	name string
+	f    *Func
+}
+
+type byStart []*indexEntry
+
+func (s byStart) Len() int           { return len(s) }
+func (s byStart) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byStart) Less(i, j int) bool { return s[i].f.Start < s[j].f.Start }
+
+// FuncIndex is an index of functions sorted by their start addresses,
+// for looking up the function that contains an address.
+type FuncIndex struct {
+	funcs []*indexEntry
+}
+
+// NewFuncIndex creates the function index of a debug table.
+func NewFuncIndex(t *Table) *FuncIndex {
+	ret := new(FuncIndex)
+	for name, f := range t.Funcs {
+		ret.funcs = append(ret.funcs, &indexEntry{name: name, f: f})
+	}
+	sort.Sort(byStart(ret.funcs))
+	return ret
+}
+
+// Find finds the function that contains the address. It returns nil
+// when the address is not in any function.
+func (x *FuncIndex) Find(addr uint32) (string, *Func) {
+	i := sort.Search(len(x.funcs), func(i int) bool {
+		return x.funcs[i].f.Start > addr
+	})
+	if i == 0 {
+		return "", nil
+	}
+	e := x.funcs[i-1]
+	if addr-e.f.Start >= e.f.Size {
+		return "", nil
+	}
+	return e.name, e.f
+}
+
+// Symbol returns the address in the form of func+offset, or the hex
+// address when the address is not in any function.
+func (x *FuncIndex) Symbol(addr uint32) string {
+	name, f := x.Find(addr)
+	if f == nil {
+		return fmt.Sprintf("%08x", addr)
+	}
+	return fmt.Sprintf("%s+%d", name, addr-f.Start)
+}
@@ -0,0 +1,168 @@
+package gdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"shanhu.io/smlvm/arch"
+)
+
+const (
+	replyOK  = "OK"
+	replyErr = "E01"
+)
+
+// stopReply converts a debugger stop into a stop reply packet.
+func (s *Stub) stopReply(stop *arch.Stop) string {
+	s.core = byte(stop.Core)
+	thread := fmt.Sprintf("thread:%x;", stop.Core+1)
+	switch stop.Reason {
+	case arch.StopWatch:
+		kind := "rwatch"
+		if stop.Write {
+			kind = "watch"
+		}
+		return fmt.Sprintf("T05%s:%x;%s", kind, stop.Addr, thread)
+	case arch.StopExcep:
+		e := stop.Excep
+		switch {
+		case arch.IsHalt(e):
+			return "W00"
+		case arch.IsErr(e, arch.ErrInvalidInst):
+			return "T04" + thread // SIGILL
+		case arch.IsErr(e, arch.ErrOutOfRange),
+			arch.IsErr(e, arch.ErrMisalign),
+			arch.IsErr(e, arch.ErrPageFault),
+			arch.IsErr(e, arch.ErrPageReadonly):
+			return "T0b" + thread // SIGSEGV
+		}
+		return "T06" + thread // SIGABRT
+	}
+	return "T05" + thread // SIGTRAP
+}
+
+func parseHex(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(v), nil
+}
+
+// parseAddrLen parses "addr,len".
+func parseAddrLen(s string) (uint32, uint32, error) {
+	parts := strings.SplitN(s, ",", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid range %q", s)
+	}
+	addr, err := parseHex(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	n, err := parseHex(parts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return addr, n, nil
+}
+
+func (s *Stub) handle(p string) (string, bool) {
+	if p == "" {
+		return "", false
+	}
+	body := p[1:]
+	switch p[0] {
+	case '?':
+		return fmt.Sprintf("T05thread:%x;", s.core+1), false
+	case 'g':
+		return s.readRegs(), false
+	case 'G':
+		return s.writeRegs(body), false
+	case 'p':
+		return s.readReg(body), false
+	case 'P':
+		return s.writeReg(body), false
+	case 'm':
+		return s.readMem(body), false
+	case 'M':
+		return s.writeMem(body), false
+	case 'c':
+		return s.cont(), false
+	case 's':
+		return s.stopReply(s.d.Step(s.core)), false
+	case 'Z', 'z':
+		return s.point(p[0] == 'Z', body), false
+	case 'H':
+		return s.selectThread(body), false
+	case 'T':
+		return s.threadAlive(body), false
+	case 'q':
+		return s.query(body), false
+	case 'Q':
+		if body == "StartNoAckMode" {
+			defer func() { s.c.noAck = true }()
+			return replyOK, false
+		}
+		return "", false
+	case 'D':
+		return replyOK, true
+	case 'k':
+		return "", true
+	}
+	return "", false
+}
+
+func (s *Stub) thread(id string) (byte, bool) {
+	if id == "0" || id == "-1" {
+		return s.core, true
+	}
+	v, err := strconv.ParseUint(id, 16, 8)
+	if err != nil || v == 0 || int(v) > s.d.Ncore() {
+		return 0, false
+	}
+	return byte(v - 1), true
+}
+
+func (s *Stub) selectThread(body string) string {
+	if len(body) < 1 {
+		return replyErr
+	}
+	core, ok := s.thread(body[1:])
+	if !ok {
+		return replyErr
+	}
+	if body[0] == 'g' || body[0] == 'c' {
+		s.core = core
+	}
+	return replyOK
+}
+
+func (s *Stub) threadAlive(body string) string {
+	if _, ok := s.thread(body); !ok {
+		return replyErr
+	}
+	return replyOK
+}
+
+func (s *Stub) query(body string) string {
+	switch {
+	case strings.HasPrefix(body, "Supported"):
+		return "PacketSize=4000;QStartNoAckMode+"
+	case body == "Attached":
+		return "1"
+	case body == "C":
+		return fmt.Sprintf("QC%x", s.core+1)
+	case body == "fThreadInfo":
+		var ids []string
+		for i := 0; i < s.d.Ncore(); i++ {
+			ids = append(ids, strconv.FormatInt(int64(i+1), 16))
+		}
+		return "m" + strings.Join(ids, ",")
+	case body == "sThreadInfo":
+		return "l"
+	case strings.HasPrefix(body, "Symbol:"):
+		return s.symbol(strings.TrimPrefix(body, "Symbol:"))
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package gdb
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// interruptPacket is returned by readPacket when the front-end sends a
+// break (Ctrl-C) byte.
+const interruptPacket = "\x03"
+
+var errBadChecksum = errors.New("bad packet checksum")
+
+// conn reads and writes packets in the GDB remote serial protocol
+// framing: $<data>#<checksum>.
+type conn struct {
+	r     *bufio.Reader
+	w     io.Writer
+	noAck bool
+}
+
+func newConn(rw io.ReadWriter) *conn {
+	return &conn{r: bufio.NewReader(rw), w: rw}
+}
+
+func checksum(bs []byte) byte {
+	var ret byte
+	for _, b := range bs {
+		ret += b
+	}
+	return ret
+}
+
+func unescape(bs []byte) []byte {
+	if bytes.IndexByte(bs, '}') < 0 {
+		return bs
+	}
+	ret := make([]byte, 0, len(bs))
+	for i := 0; i < len(bs); i++ {
+		if bs[i] == '}' && i+1 < len(bs) {
+			i++
+			ret = append(ret, bs[i]^0x20)
+		} else {
+			ret = append(ret, bs[i])
+		}
+	}
+	return ret
+}
+
+// readPacket reads the next packet. Acks are skipped.
+func (c *conn) readPacket() (string, error) {
+	for {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		switch b {
+		case '$':
+		case 0x03:
+			return interruptPacket, nil
+		default: // acks, nacks and noise
+			continue
+		}
+
+		data, err := c.r.ReadBytes('#')
+		if err != nil {
+			return "", err
+		}
+		data = data[:len(data)-1]
+
+		var sum [2]byte
+		if _, err := io.ReadFull(c.r, sum[:]); err != nil {
+			return "", err
+		}
+		var want byte
+		if _, err := fmt.Sscanf(string(sum[:]), "%02x", &want); err != nil {
+			return "", err
+		}
+
+		if !c.noAck {
+			ack := "+"
+			if checksum(data) != want {
+				ack = "-"
+			}
+			if _, err := io.WriteString(c.w, ack); err != nil {
+				return "", err
+			}
+			if ack == "-" {
+				continue // the front-end will resend
+			}
+		} else if checksum(data) != want {
+			return "", errBadChecksum
+		}
+
+		return string(unescape(data)), nil
+	}
+}
+
+func escape(s string) []byte {
+	ret := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		switch b {
+		case '$', '#', '}', '*':
+			ret = append(ret, '}', b^0x20)
+		default:
+			ret = append(ret, b)
+		}
+	}
+	return ret
+}
+
+// writePacket writes a packet. It does not wait for the ack; acks are
+// skipped when reading the next packet.
+func (c *conn) writePacket(s string) error {
+	data := escape(s)
+	buf := new(bytes.Buffer)
+	buf.WriteByte('$')
+	buf.Write(data)
+	fmt.Fprintf(buf, "#%02x", checksum(data))
+	_, err := c.w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,154 @@
+package gdb
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"shanhu.io/smlvm/arch"
+)
+
+func regHex(v uint32) string {
+	var buf [arch.RegSize]byte
+	arch.Endian.PutUint32(buf[:], v)
+	return hex.EncodeToString(buf[:])
+}
+
+func parseRegHex(s string) (uint32, bool) {
+	bs, err := hex.DecodeString(s)
+	if err != nil || len(bs) != arch.RegSize {
+		return 0, false
+	}
+	return arch.Endian.Uint32(bs), true
+}
+
+func (s *Stub) readRegs() string {
+	var ret []string
+	for _, v := range s.d.Regs(s.core) {
+		ret = append(ret, regHex(v))
+	}
+	return strings.Join(ret, "")
+}
+
+func (s *Stub) writeRegs(body string) string {
+	const n = arch.RegSize * 2
+	if len(body) != arch.Nreg*n {
+		return replyErr
+	}
+	var vs []uint32
+	for i := 0; i < arch.Nreg; i++ {
+		v, ok := parseRegHex(body[i*n : (i+1)*n])
+		if !ok {
+			return replyErr
+		}
+		vs = append(vs, v)
+	}
+	for i, v := range vs {
+		s.d.SetReg(s.core, i, v)
+	}
+	return replyOK
+}
+
+func parseReg(s string) (int, bool) {
+	r, err := strconv.ParseUint(s, 16, 8)
+	if err != nil || r >= arch.Nreg {
+		return 0, false
+	}
+	return int(r), true
+}
+
+func (s *Stub) readReg(body string) string {
+	r, ok := parseReg(body)
+	if !ok {
+		return replyErr
+	}
+	return regHex(s.d.Regs(s.core)[r])
+}
+
+func (s *Stub) writeReg(body string) string {
+	parts := strings.SplitN(body, "=", 2)
+	if len(parts) != 2 {
+		return replyErr
+	}
+	r, ok := parseReg(parts[0])
+	if !ok {
+		return replyErr
+	}
+	v, ok := parseRegHex(parts[1])
+	if !ok {
+		return replyErr
+	}
+	s.d.SetReg(s.core, r, v)
+	return replyOK
+}
+
+func (s *Stub) readMem(body string) string {
+	addr, n, err := parseAddrLen(body)
+	if err != nil {
+		return replyErr
+	}
+	bs, err := s.d.ReadBytes(s.core, addr, int(n))
+	if err != nil {
+		return replyErr
+	}
+	return hex.EncodeToString(bs)
+}
+
+func (s *Stub) writeMem(body string) string {
+	parts := strings.SplitN(body, ":", 2)
+	if len(parts) != 2 {
+		return replyErr
+	}
+	addr, n, err := parseAddrLen(parts[0])
+	if err != nil {
+		return replyErr
+	}
+	bs, err := hex.DecodeString(parts[1])
+	if err != nil || len(bs) != int(n) {
+		return replyErr
+	}
+	if err := s.d.WriteBytes(s.core, addr, bs); err != nil {
+		return replyErr
+	}
+	return replyOK
+}
+
+// point handles Z and z packets that insert and remove breakpoints and
+// watchpoints.
+func (s *Stub) point(insert bool, body string) string {
+	parts := strings.Split(body, ",")
+	if len(parts) != 3 {
+		return replyErr
+	}
+	addr, err := parseHex(parts[1])
+	if err != nil {
+		return replyErr
+	}
+	size, err := parseHex(parts[2])
+	if err != nil {
+		return replyErr
+	}
+
+	switch parts[0] {
+	case "0", "1": // software and hardware breakpoints
+		if insert {
+			s.d.AddBreak(addr)
+		} else {
+			s.d.RemoveBreak(addr)
+		}
+	case "2", "3", "4": // write, read and access watchpoints
+		if !insert {
+			s.d.RemoveWatch(addr)
+			return replyOK
+		}
+		s.d.AddWatch(&arch.Watchpoint{
+			Addr:  addr,
+			Size:  size,
+			Read:  parts[0] != "2",
+			Write: parts[0] != "3",
+		})
+	default:
+		return ""
+	}
+	return replyOK
+}
@@ -0,0 +1,144 @@
+// Package gdb implements a stub of the GDB remote serial protocol, so
+// that existing debugger front-ends can attach to a running arch8
+// machine.
+//
+// The stub presents each core as a thread, with thread id being the
+// core index plus one. Registers are presented in the order of r0-r4,
+// sp, ret and pc, each a 32-bit little endian value.
+package gdb
+
+import (
+	"io"
+	"net"
+
+	"shanhu.io/smlvm/arch"
+	"shanhu.io/smlvm/debug"
+)
+
+// contTicks is the number of ticks to run before checking for a break
+// request from the front-end when continuing.
+const contTicks = 10000
+
+// Stub serves the GDB remote serial protocol on a debugger.
+type Stub struct {
+	d     *arch.Debugger
+	table *debug.Table
+	core  byte
+
+	lookups []string // symbols to ask the front-end for
+	symErr  error
+
+	c       *conn
+	pkts    chan string
+	pending []string // packets received while running
+	done    chan struct{}
+}
+
+// NewStub creates a new stub that controls the debugger. core is the
+// core that is selected initially. The functions in the debug table
+// are looked up with qSymbol, and the table can be nil.
+func NewStub(d *arch.Debugger, core byte, t *debug.Table) *Stub {
+	return &Stub{d: d, table: t, core: core}
+}
+
+func (s *Stub) readLoop(errs chan<- error) {
+	for {
+		p, err := s.c.readPacket()
+		if err != nil {
+			errs <- err
+			return
+		}
+		select {
+		case s.pkts <- p:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Serve serves a front-end session on the connection until the
+// front-end detaches, kills the session or closes the connection.
+func (s *Stub) Serve(rw io.ReadWriter) error {
+	s.c = newConn(rw)
+	s.pkts = make(chan string)
+	s.done = make(chan struct{})
+	defer close(s.done)
+
+	errs := make(chan error, 1)
+	go s.readLoop(errs)
+
+	for {
+		var p string
+		if len(s.pending) > 0 {
+			p, s.pending = s.pending[0], s.pending[1:]
+		} else {
+			select {
+			case p = <-s.pkts:
+			case err := <-errs:
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+		}
+
+		if p == interruptPacket {
+			if err := s.c.writePacket("S02"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		resp, quit := s.handle(p)
+		if err := s.c.writePacket(resp); err != nil {
+			return err
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// interrupted checks if the front-end has sent a break request. Other
+// packets are queued, and are handled after the stop reply.
+func (s *Stub) interrupted() bool {
+	for {
+		select {
+		case p := <-s.pkts:
+			if p == interruptPacket {
+				return true
+			}
+			s.pending = append(s.pending, p)
+		default:
+			return false
+		}
+	}
+}
+
+func (s *Stub) cont() string {
+	for {
+		_, stop := s.d.Continue(contTicks)
+		if stop != nil {
+			return s.stopReply(stop)
+		}
+		if s.interrupted() {
+			return "S02"
+		}
+	}
+}
+
+// ListenAndServe listens on a TCP address, and serves the first
+// front-end that connects.
+func ListenAndServe(addr string, s *Stub) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	conn, err := lis.Accept()
+	lis.Close()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return s.Serve(conn)
+}
@@ -0,0 +1,157 @@
+package gdb
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"shanhu.io/smlvm/arch"
+	asminst "shanhu.io/smlvm/asm/inst"
+	"shanhu.io/smlvm/debug"
+)
+
+type testClient struct {
+	t *testing.T
+	c net.Conn
+	r *bufio.Reader
+}
+
+func (c *testClient) send(p string) {
+	fmt.Fprintf(c.c, "$%s#%02x", p, checksum([]byte(p)))
+}
+
+func (c *testClient) recv() string {
+	for {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		if b == '$' {
+			break
+		}
+	}
+	data, err := c.r.ReadString('#')
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err := c.r.Discard(2); err != nil {
+		c.t.Fatal(err)
+	}
+	c.c.Write([]byte("+"))
+	return strings.TrimSuffix(data, "#")
+}
+
+func (c *testClient) call(p string) string {
+	c.send(p)
+	return c.recv()
+}
+
+func TestStub(t *testing.T) {
+	m := arch.NewMachine(&arch.Config{InitPC: arch.InitPC})
+	d := arch.NewDebugger(m)
+	insts := []uint32{
+		asminst.Imm(arch.ADDI, arch.R1, arch.R0, 7),
+		asminst.Imm(arch.ADDI, arch.R1, arch.R1, 1),
+		asminst.Sys(arch.HALT, 0, 0),
+	}
+	for i, in := range insts {
+		addr := arch.InitPC + uint32(i)*4
+		if err := d.WriteWord(0, addr, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tab := debug.NewTable()
+	tab.Funcs["main.main"] = &debug.Func{Start: arch.InitPC, Size: 12}
+	tab.Funcs["main.f"] = &debug.Func{Start: arch.InitPC + 12, Size: 4}
+
+	server, client := net.Pipe()
+	stub := NewStub(d, 0, tab)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- stub.Serve(server)
+	}()
+
+	c := &testClient{t: t, c: client, r: bufio.NewReader(client)}
+	expect := func(p, want string) {
+		if got := c.call(p); got != want {
+			t.Errorf("%q: got %q, want %q", p, got, want)
+		}
+	}
+
+	expect("qfThreadInfo", "m1")
+	expect("Z0,8004,4", "OK")
+	expect("c", "T05thread:1;")
+	expect("p1", "07000000")
+	expect("p7", "04800000")
+	expect("m8000,4", "07002001")
+	expect("s", "T05thread:1;")
+	expect("P1=10000000", "OK")
+	expect("p1", "10000000")
+	expect("qSymbol::", "qSymbol:6d61696e2e66") // main.f
+	expect("qSymbol::6d61696e2e66", "qSymbol:6d61696e2e6d61696e")
+	expect("qSymbol:8000:6d61696e2e6d61696e", "OK")
+	expect("c", "W00")
+	expect("D", "OK")
+
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if err := stub.SymbolErr(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStubSymbolMismatch(t *testing.T) {
+	m := arch.NewMachine(&arch.Config{InitPC: arch.InitPC})
+	tab := debug.NewTable()
+	tab.Funcs["main.main"] = &debug.Func{Start: arch.InitPC, Size: 4}
+	stub := NewStub(arch.NewDebugger(m), 0, tab)
+
+	if got := stub.symbol(":"); got != "qSymbol:6d61696e2e6d61696e" {
+		t.Fatalf("got %q on offer", got)
+	}
+	if got := stub.symbol("8100:6d61696e2e6d61696e"); got != replyOK {
+		t.Errorf("got %q on answer, want OK", got)
+	}
+	if stub.SymbolErr() == nil {
+		t.Error("want a symbol mismatch error")
+	}
+}
+
+func TestStubPacketsWhileRunning(t *testing.T) {
+	m := arch.NewMachine(&arch.Config{InitPC: arch.InitPC})
+	d := arch.NewDebugger(m)
+	loop := asminst.Jmp(arch.J, -1)
+	if err := d.WriteWord(0, arch.InitPC, loop); err != nil {
+		t.Fatal(err)
+	}
+
+	server, client := net.Pipe()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- NewStub(d, 0, nil).Serve(server)
+	}()
+
+	c := &testClient{t: t, c: client, r: bufio.NewReader(client)}
+	if got := c.call("QStartNoAckMode"); got != "OK" {
+		t.Fatalf("no ack mode: got %q", got)
+	}
+	c.send("c")
+	c.send("qfThreadInfo") // queued until the stop
+	client.Write([]byte{0x03})
+	if got := c.recv(); got != "S02" {
+		t.Errorf("continue: got %q, want S02", got)
+	}
+	if got := c.recv(); got != "m1" {
+		t.Errorf("thread info: got %q, want m1", got)
+	}
+	if got := c.call("D"); got != "OK" {
+		t.Errorf("detach: got %q, want OK", got)
+	}
+
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+}
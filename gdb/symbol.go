@@ -0,0 +1,72 @@
+package gdb
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// symbol serves the qSymbol exchange. The front-end offers symbol
+// lookups with "qSymbol::". The stub then asks for the functions in the
+// debug table one by one with "qSymbol:<name>", and the front-end
+// answers each with "qSymbol:<value>:<name>", or with "qSymbol::<name>"
+// when it does not know the symbol. The stub checks the values against
+// the debug table, and ends the exchange with OK.
+func (s *Stub) symbol(body string) string {
+	parts := strings.SplitN(body, ":", 2)
+	if len(parts) != 2 {
+		return replyErr
+	}
+	if parts[1] == "" { // a new offer
+		s.lookups = s.lookups[:0]
+		if s.table != nil {
+			for name := range s.table.Funcs {
+				s.lookups = append(s.lookups, name)
+			}
+			sort.Strings(s.lookups)
+		}
+	} else if err := s.checkSymbol(parts[0], parts[1]); err != nil {
+		if s.symErr == nil {
+			s.symErr = err
+		}
+	}
+
+	if len(s.lookups) == 0 {
+		return replyOK
+	}
+	name := s.lookups[0]
+	s.lookups = s.lookups[1:]
+	return "qSymbol:" + hex.EncodeToString([]byte(name))
+}
+
+func (s *Stub) checkSymbol(value, hexName string) error {
+	bs, err := hex.DecodeString(hexName)
+	if err != nil {
+		return err
+	}
+	name := string(bs)
+	if value == "" || s.table == nil {
+		return nil // unknown to the front-end
+	}
+	v, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return fmt.Errorf("symbol %q: %v", name, err)
+	}
+	f, found := s.table.Funcs[name]
+	if !found {
+		return nil
+	}
+	if uint32(v) != f.Start {
+		return fmt.Errorf("symbol %q is at %x, but the front-end has %x",
+			name, f.Start, v,
+		)
+	}
+	return nil
+}
+
+// SymbolErr returns the first mismatch found between the symbols of the
+// front-end and the debug table, which usually means that the
+// front-end loaded a different program.
+func (s *Stub) SymbolErr() error { return s.symErr }
@@ -31,7 +31,7 @@ func NewVar(align uint32) *Var {
 
 	if align == 0 {
 		align = 1
-	} else if align != 1 && align != 4 {
+	} else if align != 1 && align != 2 && align != 4 {
 		panic("invalid align")
 	}
 
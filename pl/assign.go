@@ -49,16 +49,19 @@ func opAssign(b *builder, dest, src *ref, op string) {
 	}
 
 	switch t {
-	case types.Int, types.Int8:
+	case types.Int, types.Int8, types.Int16:
 		buildBasicArith(b, dest, dest, src, opOp)
 		return
-	case types.Uint, types.Uint8:
+	case types.Uint, types.Uint8, types.Uint16:
 		switch opOp {
 		case "*", "/", "%":
 			opOp = "u" + opOp
 		}
 		buildBasicArith(b, dest, dest, src, opOp)
 		return
+	case types.Float32:
+		b.b.Arith(dest.IR(), dest.IR(), "f"+opOp, src.IR())
+		return
 	}
 
 	panic("bug")
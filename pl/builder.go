@@ -55,7 +55,7 @@ func (b *builder) anonyName(name string) string {
 }
 
 func (b *builder) newTempIR(t types.T) codegen.Ref {
-	return b.f.NewTemp(t.Size(), types.IsByte(t), t.RegSizeAlign())
+	return b.f.NewTemp(t.Size(), types.IsUnsigned(t), t.RegSizeAlign())
 }
 
 func (b *builder) newTemp(t types.T) *ref { return newRef(t, b.newTempIR(t)) }
@@ -69,13 +69,15 @@ func (b *builder) newAddressableTemp(t types.T) *ref {
 
 func (b *builder) newLocal(t types.T, name string) codegen.Ref {
 	return b.f.NewLocal(t.Size(), name,
-		types.IsByte(t), t.RegSizeAlign(),
+		types.IsUnsigned(t), t.RegSizeAlign(),
 	)
 }
 
 func (b *builder) newGlobalVar(t types.T, name string) codegen.Ref {
 	name = b.anonyName(name)
-	return b.p.NewGlobalVar(t.Size(), name, types.IsByte(t), t.RegSizeAlign())
+	return b.p.NewGlobalVar(t.Size(), name,
+		types.IsUnsigned(t), t.RegSizeAlign(),
+	)
 }
 
 func (b *builder) buildExpr(expr tast.Expr) *ref {
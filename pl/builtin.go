@@ -49,11 +49,24 @@ func declareBuiltin(b *builder, builtin *link.Pkg) {
 	o("PrintInt32", "printInt", types.NewVoidFunc(types.Int))
 	o("PrintUint32", "printUint", types.NewVoidFunc(types.Uint))
 	o("PrintChar", "printChar", types.NewVoidFunc(types.Int8))
+	o("PrintFloat32", "printFloat", types.NewVoidFunc(types.Float32))
+	o("ReadChar", "readChar", types.NewFuncUnamed(nil, []types.T{types.Int}))
 	b.panicFunc = o("Panic", "panic", types.VoidFunc)
 	o("Sleep", "sleep", types.VoidFunc)
 	o("IOCall", "iocall", types.VoidFunc)
 	o("Assert", "assert", types.NewVoidFunc(types.Bool))
 
+	pint := types.NewPointer(types.Int)
+	o("CompareAndSwap", "compareAndSwap", types.NewFuncUnamed(
+		[]types.T{pint, types.Int, types.Int}, []types.T{types.Bool},
+	))
+	o("AtomicAdd", "atomicAdd", types.NewFuncUnamed(
+		[]types.T{pint, types.Int}, []types.T{types.Int},
+	))
+	o("AtomicSwap", "atomicSwap", types.NewFuncUnamed(
+		[]types.T{pint, types.Int}, []types.T{types.Int},
+	))
+
 	bi := func(name string) {
 		t := types.NewBuiltInFunc(name)
 		obj := &objFunc{name: name, ref: newRef(t, nil)}
@@ -66,6 +79,8 @@ func declareBuiltin(b *builder, builtin *link.Pkg) {
 
 	bi("len")
 	bi("make")
+	bi("mulHigh")
+	bi("mulHighU")
 
 	c := func(name string, r *ref) {
 		// TODO: declare these as typed consts
@@ -95,6 +110,8 @@ func declareBuiltin(b *builder, builtin *link.Pkg) {
 	t("uint32", types.Uint)
 	t("int8", types.Int8)
 	t("uint8", types.Uint8)
+	t("int16", types.Int16)
+	t("uint16", types.Uint16)
 	t("char", types.Int8)
 	t("byte", types.Uint8)
 	t("bool", types.Bool)
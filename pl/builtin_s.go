@@ -26,6 +26,32 @@ func PrintChar {
 	mov pc ret
 }
 
+// ReadChar waits and reads a char from the console input
+// returns the char in r1, or -1 at the end of the input
+func ReadChar {
+	addi sp sp -4
+	sw r2 sp
+
+	ori r2 r0 0x2000 // the address of serial port
+.wait
+	lbu r1 r2 5
+	beq r1 r0 .wait // wait for valid
+
+	addi r1 r1 -1
+	bne r1 r0 .eof
+	lbu r1 r2 4
+	sb r0 r2 5 // consume the char
+	j .ret
+
+.eof
+	addi r1 r0 -1
+
+.ret
+	lw r2 sp
+	addi sp sp 4
+	mov pc ret
+}
+
 // Print a 32-bit unsigned integer
 // when array is implemented, this will be rewritten in glang
 func PrintUint32 {
@@ -102,6 +128,154 @@ func PrintInt32 {
 	lw pc sp -4
 }
 
+// PrintFloat32 prints a float in the form of +d.dddddde+ddd, like
+// println in Go, followed by an end line. The last digit might be off
+// by one for very large or very small numbers.
+func PrintFloat32 {
+	sw ret sp -4
+	addi sp sp -20
+	sw r2 sp
+	sw r3 sp 4
+	sw r4 sp 8
+
+	mov r2 r1
+	feq r4 r2 r2
+	beq r4 r0 .nan
+
+	addi r1 r0 0x2b // '+'
+	slt r4 r2 r0 // the sign bit
+	beq r4 r0 .sign
+	addi r1 r0 0x2d // '-'
+	fabs r2 r2
+.sign
+	jal PrintChar
+
+	lui r4 0x7f80 // +inf
+	beq r2 r4 .inf
+
+	// normalize the float into [1, 10), r3 is the exponent
+	mov r3 r0
+	beq r2 r0 .digits
+	lui r4 0x5015
+	ori r4 r4 0x02f9 // 1e10, which is exact
+.small
+	lui r1 0x3f80 // 1.0
+	fle r1 r1 r2
+	bne r1 r0 .big
+	fmul r2 r2 r4
+	addi r3 r3 -10
+	j .small
+.big
+	flt r1 r2 r4
+	bne r1 r0 .scale
+	fdiv r2 r2 r4
+	addi r3 r3 10
+	j .big
+
+.scale
+	// finds the largest power of 10 that is not larger than the float,
+	// and divides by it, so that there is only one rounding.
+	sw r3 sp 12
+	lui r4 0x3f80 // 1.0
+	lui r3 0x4120 // 10.0
+.scaleloop
+	fmul r1 r4 r3
+	flt r1 r2 r1
+	bne r1 r0 .scaled
+	fmul r4 r4 r3
+	lw r1 sp 12
+	addi r1 r1 1
+	sw r1 sp 12
+	j .scaleloop
+.scaled
+	fdiv r2 r2 r4
+	lw r3 sp 12
+
+.digits
+	// r2 = round(f * 1e6), which has 7 digits
+	lui r4 0x4974
+	ori r4 r4 0x2400 // 1e6
+	fmul r2 r2 r4
+	lui r4 0x3f00 // 0.5
+	fadd r2 r2 r4
+	fint r2 r2
+	lui r4 0x98
+	ori r4 r4 0x9680 // 1e7
+	sltu r1 r2 r4
+	bne r1 r0 .print
+	ori r4 r0 10 // rounded up to 10.000000
+	divu r2 r2 r4
+	addi r3 r3 1
+
+.print
+	sw r3 sp 12 // save the exponent
+	ori r3 r0 10
+	lui r4 0xf
+	ori r4 r4 0x4240 // 1e6
+	divu r1 r2 r4
+	modu r2 r2 r4
+	addi r1 r1 0x30
+	jal PrintChar
+	addi r1 r0 0x2e // '.'
+	jal PrintChar
+.frac
+	divu r4 r4 r3
+	beq r4 r0 .exp
+	divu r1 r2 r4
+	modu r2 r2 r4
+	addi r1 r1 0x30
+	jal PrintChar
+	j .frac
+
+.exp
+	addi r1 r0 0x65 // 'e'
+	jal PrintChar
+	lw r2 sp 12
+	addi r1 r0 0x2b // '+'
+	slt r4 r2 r0
+	beq r4 r0 .esign
+	addi r1 r0 0x2d // '-'
+	sub r2 r0 r2
+.esign
+	jal PrintChar
+	ori r4 r0 100
+.edigits
+	divu r1 r2 r4
+	modu r2 r2 r4
+	addi r1 r1 0x30
+	jal PrintChar
+	divu r4 r4 r3
+	bne r4 r0 .edigits
+	j .end
+
+.nan
+	addi r1 r0 0x4e // 'N'
+	jal PrintChar
+	addi r1 r0 0x61 // 'a'
+	jal PrintChar
+	addi r1 r0 0x4e // 'N'
+	jal PrintChar
+	j .end
+
+.inf
+	addi r1 r0 0x49 // 'I'
+	jal PrintChar
+	addi r1 r0 0x6e // 'n'
+	jal PrintChar
+	addi r1 r0 0x66 // 'f'
+	jal PrintChar
+
+.end
+	addi r1 r0 0xa
+	jal PrintChar // print a end line
+
+	lw r2 sp
+	lw r3 sp 4
+	lw r4 sp 8
+	addi sp sp 20
+	lw pc sp -4
+}
+
 // Panic halts the system immediately with panic exception
 func Panic {
 	panic
@@ -207,4 +381,39 @@ func Syscall {
 	syscall
 	mov pc ret
 }
+
+// CompareAndSwap atomically stores a new word if the old word is
+// the same as expected
+//    r1 - the address of the word
+//    r2 - the expected old word
+//    r3 - the new word
+// returns 1 in r1 if the new word is stored, 0 otherwise
+func CompareAndSwap {
+	mov r4 r2
+	cas r2 r1 r3 // r2 = old
+	xor r1 r2 r4
+	sltu r1 r0 r1 // r1 = old != expected
+	xori r1 r1 1
+	mov pc ret
+}
+
+// AtomicAdd atomically adds to a word
+//    r1 - the address of the word
+//    r2 - the delta
+// returns the new word in r1
+func AtomicAdd {
+	amoadd r3 r1 r2
+	add r1 r3 r2
+	mov pc ret
+}
+
+// AtomicSwap atomically swaps a word
+//    r1 - the address of the word
+//    r2 - the new word
+// returns the old word in r1
+func AtomicSwap {
+	amoswap r2 r1 r2
+	mov r1 r2
+	mov pc ret
+}
 `
@@ -33,6 +33,13 @@ func buildCallMake(b *builder, expr *tast.CallExpr) *ref {
 	return newSlice(b, t.T, start, size)
 }
 
+func buildCallMulHigh(b *builder, expr *tast.CallExpr, op string) *ref {
+	args := b.buildExpr(expr.Args)
+	ret := b.newTemp(expr.R().T)
+	b.b.Arith(ret.IR(), args.At(0).IR(), op, args.At(1).IR())
+	return ret
+}
+
 func buildCallExpr(b *builder, expr *tast.CallExpr) *ref {
 	f := b.buildExpr(expr.Func)
 	builtin, ok := f.Type().(*types.BuiltInFunc)
@@ -42,6 +49,10 @@ func buildCallExpr(b *builder, expr *tast.CallExpr) *ref {
 			return buildCallLen(b, expr)
 		case "make":
 			return buildCallMake(b, expr)
+		case "mulHigh":
+			return buildCallMulHigh(b, expr, "h*")
+		case "mulHighU":
+			return buildCallMulHigh(b, expr, "uh*")
 		}
 		panic("bug")
 	}
@@ -1,6 +1,8 @@
 package pl
 
 import (
+	"math"
+
 	"shanhu.io/smlvm/arch"
 	"shanhu.io/smlvm/pl/codegen"
 	"shanhu.io/smlvm/pl/types"
@@ -35,9 +37,15 @@ func constNumIr(v int64, t types.T) codegen.Ref {
 			return codegen.Byt(uint8(v), false)
 		case types.Uint8:
 			return codegen.Byt(uint8(v), true)
+		case types.Int16:
+			return codegen.Half(uint16(v), false)
+		case types.Uint16:
+			return codegen.Half(uint16(v), true)
+		case types.Float32:
+			return codegen.Num(math.Float32bits(float32(v)))
 		}
 	}
-	panic("expect an integer type")
+	panic("expect a number type")
 }
 
 func buildCast(b *builder, from *ref, t types.T) *ref {
@@ -58,7 +66,7 @@ func buildCast(b *builder, from *ref, t types.T) *ref {
 	}
 
 	if c, ok := srcType.(*types.Const); ok {
-		if v, ok := types.NumConst(srcType); ok && types.IsInteger(t) {
+		if v, ok := types.NumConst(srcType); ok && types.IsNumeric(t) {
 			return newRef(t, constNumIr(v, t))
 		}
 		// TODO: we do not support typed const right?
@@ -74,5 +82,19 @@ func buildCast(b *builder, from *ref, t types.T) *ref {
 		b.b.Arith(ret.IR(), nil, "", from.IR())
 		return ret
 	}
+	if types.IsBasic(t, types.Float32) {
+		if types.IsBasic(srcType, types.Float32) {
+			b.b.Arith(ret.IR(), nil, "", from.IR())
+		} else if types.IsBasic(srcType, types.Uint) {
+			b.b.Arith(ret.IR(), nil, "uitof", from.IR())
+		} else {
+			b.b.Arith(ret.IR(), nil, "itof", from.IR())
+		}
+		return ret
+	}
+	if types.IsBasic(srcType, types.Float32) {
+		b.b.Arith(ret.IR(), nil, "fint", from.IR())
+		return ret
+	}
 	panic("bug")
 }
@@ -12,22 +12,24 @@ type AddrRef struct {
 	base         Ref
 	offset       int32
 	size         int32
-	u8           bool
+	unsigned     bool
 	regSizeAlign bool
 }
 
 // NewAddrRef creates an indirect reference to an address that is
 // saved at base.
-func NewAddrRef(base Ref, n, offset int32, u8, regSizeAlign bool) Ref {
-	return newAddrRef(base, n, offset, u8, regSizeAlign)
+func NewAddrRef(base Ref, n, offset int32, unsigned, regSizeAlign bool) Ref {
+	return newAddrRef(base, n, offset, unsigned, regSizeAlign)
 }
 
-func newAddrRef(base Ref, n, offset int32, u8, regSizeAlign bool) *AddrRef {
+func newAddrRef(
+	base Ref, n, offset int32, unsigned, regSizeAlign bool,
+) *AddrRef {
 	return &AddrRef{
 		base:         base,
 		size:         n,
 		offset:       offset,
-		u8:           u8,
+		unsigned:     unsigned,
 		regSizeAlign: regSizeAlign,
 	}
 }
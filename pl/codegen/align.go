@@ -13,3 +13,15 @@ func alignUp(size, align int32) int32 {
 	}
 	return size + align - mod
 }
+
+// alignOf returns the alignment of a value. Values that hold halfwords
+// but no words have even sizes, and are halfword aligned.
+func alignOf(size int32, regSizeAlign bool) int32 {
+	if regSizeAlign {
+		return regSize
+	}
+	if size%2 == 0 {
+		return 2
+	}
+	return 1
+}
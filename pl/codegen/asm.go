@@ -41,6 +41,15 @@ func (_s) lb(d, s uint32, im int32) uint32 {
 func (_s) lbu(d, s uint32, im int32) uint32 {
 	return asm.ims(A.LBU, d, s, im)
 }
+func (_s) sh(d, s uint32, im int32) uint32 {
+	return asm.ims(A.SH, d, s, im)
+}
+func (_s) lh(d, s uint32, im int32) uint32 {
+	return asm.ims(A.LH, d, s, im)
+}
+func (_s) lhu(d, s uint32, im int32) uint32 {
+	return asm.ims(A.LHU, d, s, im)
+}
 func (_s) addi(d, s uint32, im int32) uint32 {
 	return asm.ims(A.ADDI, d, s, im)
 }
@@ -58,6 +67,10 @@ func (_s) add(d, s1, s2 uint32) uint32  { return asm.reg(A.ADD, d, s1, s2) }
 func (_s) sub(d, s1, s2 uint32) uint32  { return asm.reg(A.SUB, d, s1, s2) }
 func (_s) mul(d, s1, s2 uint32) uint32  { return asm.reg(A.MUL, d, s1, s2) }
 func (_s) mulu(d, s1, s2 uint32) uint32 { return asm.reg(A.MULU, d, s1, s2) }
+func (_s) mulh(d, s1, s2 uint32) uint32 { return asm.reg(A.MULH, d, s1, s2) }
+func (_s) mulhu(d, s1, s2 uint32) uint32 {
+	return asm.reg(A.MULHU, d, s1, s2)
+}
 func (_s) div(d, s1, s2 uint32) uint32  { return asm.reg(A.DIV, d, s1, s2) }
 func (_s) divu(d, s1, s2 uint32) uint32 { return asm.reg(A.DIVU, d, s1, s2) }
 func (_s) mod(d, s1, s2 uint32) uint32  { return asm.reg(A.MOD, d, s1, s2) }
@@ -72,6 +85,21 @@ func (_s) sllv(d, s1, s2 uint32) uint32 { return asm.reg(A.SLLV, d, s1, s2) }
 func (_s) srla(d, s1, s2 uint32) uint32 { return asm.reg(A.SRLA, d, s1, s2) }
 func (_s) srlv(d, s1, s2 uint32) uint32 { return asm.reg(A.SRLV, d, s1, s2) }
 
+func (_s) freg(op, d, s1, s2 uint32) uint32 {
+	return S.Reg(op, d, s1, s2, 0, 1)
+}
+
+func (_s) fadd(d, s1, s2 uint32) uint32 { return asm.freg(A.FADD, d, s1, s2) }
+func (_s) fsub(d, s1, s2 uint32) uint32 { return asm.freg(A.FSUB, d, s1, s2) }
+func (_s) fmul(d, s1, s2 uint32) uint32 { return asm.freg(A.FMUL, d, s1, s2) }
+func (_s) fdiv(d, s1, s2 uint32) uint32 { return asm.freg(A.FDIV, d, s1, s2) }
+func (_s) feq(d, s1, s2 uint32) uint32  { return asm.freg(A.FEQ, d, s1, s2) }
+func (_s) flt(d, s1, s2 uint32) uint32  { return asm.freg(A.FLT, d, s1, s2) }
+func (_s) fle(d, s1, s2 uint32) uint32  { return asm.freg(A.FLE, d, s1, s2) }
+func (_s) fneg(d, s uint32) uint32      { return asm.freg(A.FNEG, d, s, 0) }
+func (_s) fint(d, s uint32) uint32      { return asm.freg(A.FINT, d, s, 0) }
+func (_s) itof(d, s uint32) uint32      { return asm.freg(A.ITOF, d, s, 0) }
+
 func (_s) srl(d, s1, v uint32) uint32 {
 	return S.Reg(A.SRL, d, s1, 0, v, 0)
 }
@@ -65,8 +65,8 @@ func (f *Func) RetRefs() []Ref {
 }
 
 // NewLocal creates a new named local variable of size n on the stack.
-func (f *Func) NewLocal(n int32, name string, u8, regSizeAlign bool) Ref {
-	ret := NewVar(n, name, u8, regSizeAlign)
+func (f *Func) NewLocal(n int32, name string, unsigned, regSizeAlign bool) Ref {
+	ret := NewVar(n, name, unsigned, regSizeAlign)
 	f.locals = append(f.locals, ret)
 	return ret
 }
@@ -78,8 +78,8 @@ func (f *Func) newTempName() string {
 }
 
 // NewTemp creates a new temp variable of size n on the stack.
-func (f *Func) NewTemp(n int32, u8, regSizeAlign bool) Ref {
-	return f.NewLocal(n, f.newTempName(), u8, regSizeAlign)
+func (f *Func) NewTemp(n int32, unsigned, regSizeAlign bool) Ref {
+	return f.NewLocal(n, f.newTempName(), unsigned, regSizeAlign)
 }
 
 func (f *Func) newBlock(after *Block) *Block {
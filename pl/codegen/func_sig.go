@@ -4,7 +4,7 @@ package codegen
 type FuncArg struct {
 	Name         string
 	Size         int32
-	U8           bool
+	Unsigned     bool
 	RegSizeAlign bool
 }
 
@@ -25,11 +25,11 @@ type FuncSig struct {
 func NewFuncSig(args, rets []*FuncArg) *FuncSig {
 	ret := new(FuncSig)
 	for _, arg := range args {
-		v := NewVar(arg.Size, arg.Name, arg.U8, arg.RegSizeAlign)
+		v := NewVar(arg.Size, arg.Name, arg.Unsigned, arg.RegSizeAlign)
 		ret.args = append(ret.args, v)
 	}
 	for _, arg := range rets {
-		v := NewVar(arg.Size, arg.Name, arg.U8, arg.RegSizeAlign)
+		v := NewVar(arg.Size, arg.Name, arg.Unsigned, arg.RegSizeAlign)
 		ret.rets = append(ret.rets, v)
 	}
 
@@ -52,9 +52,8 @@ func layoutFuncArgs(f *FuncSig, args []*Var) ([]*Var, []bool) {
 			continue
 		} else if nreg >= viaRegMax || !arg.CanViaReg() {
 			frameSize += arg.size
-			if arg.regSizeAlign {
-				frameSize = alignUp(frameSize, regSize)
-			}
+			align := alignOf(arg.size, arg.regSizeAlign)
+			frameSize = alignUp(frameSize, align)
 			arg.Offset = frameSize
 			continue
 		}
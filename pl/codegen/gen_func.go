@@ -33,9 +33,7 @@ other args are pushed on the stack
 func pushVar(f *Func, vars ...*Var) {
 	for _, v := range vars {
 		f.frameSize += v.size
-		if v.regSizeAlign {
-			f.frameSize = alignUp(f.frameSize, regSize)
-		}
+		f.frameSize = alignUp(f.frameSize, alignOf(v.size, v.regSizeAlign))
 		v.Offset = f.frameSize
 	}
 }
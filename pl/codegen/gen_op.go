@@ -11,6 +11,8 @@ var basicOpFuncs = map[string]func(
 	"-":   asm.sub,
 	"*":   asm.mul,
 	"u*":  asm.mulu,
+	"h*":  asm.mulh,
+	"uh*": asm.mulhu,
 	"/":   asm.div,
 	"u/":  asm.divu,
 	"%":   asm.mod,
@@ -19,6 +21,14 @@ var basicOpFuncs = map[string]func(
 	"|":   asm.or,
 	"^":   asm.xor,
 	"nor": asm.nor,
+
+	"f+":  asm.fadd,
+	"f-":  asm.fsub,
+	"f*":  asm.fmul,
+	"f/":  asm.fdiv,
+	"f==": asm.feq,
+	"f<":  asm.flt,
+	"f<=": asm.fle,
 }
 
 func genArithOp(g *gener, b *Block, op *ArithOp) {
@@ -68,6 +78,13 @@ func genArithOp(g *gener, b *Block, op *ArithOp) {
 			case "u<=":
 				b.inst(asm.sltu(_r4, _r1, _r4))
 				b.inst(asm.xori(_r4, _r4, 1))
+			case "f!=":
+				b.inst(asm.feq(_r4, _r4, _r1))
+				b.inst(asm.xori(_r4, _r4, 1)) // flip
+			case "f>":
+				b.inst(asm.flt(_r4, _r1, _r4))
+			case "f>=":
+				b.inst(asm.fle(_r4, _r1, _r4))
 			case "<<":
 				b.inst(asm.sllv(_r4, _r4, _r1))
 			case ">>":
@@ -125,6 +142,28 @@ func genArithOp(g *gener, b *Block, op *ArithOp) {
 		case "-":
 			loadRef(b, _r4, op.B)
 			b.inst(asm.sub(_r4, _r0, _r4))
+		case "fneg":
+			loadRef(b, _r4, op.B)
+			b.inst(asm.fneg(_r4, _r4))
+		case "itof":
+			loadRef(b, _r4, op.B)
+			b.inst(asm.itof(_r4, _r4))
+		case "uitof":
+			// itof takes signed integers, so a uint with the high bit
+			// set is halved first, keeping the low bit for rounding.
+			loadRef(b, _r4, op.B)
+			b.inst(asm.slt(_r1, _r4, _r0))
+			b.inst(asm.beq(_r1, _r0, 6))
+			b.inst(asm.andi(_r1, _r4, 1))
+			b.inst(asm.srl(_r4, _r4, 1))
+			b.inst(asm.or(_r4, _r4, _r1))
+			b.inst(asm.itof(_r4, _r4))
+			b.inst(asm.fadd(_r4, _r4, _r4))
+			b.inst(asm.j(1))
+			b.inst(asm.itof(_r4, _r4))
+		case "fint":
+			loadRef(b, _r4, op.B)
+			b.inst(asm.fint(_r4, _r4))
 		case "!":
 			loadRef(b, _r4, op.B)
 			b.inst(asm.sltu(_r4, _r0, _r4)) // test non-zero first
@@ -0,0 +1,19 @@
+package codegen
+
+import (
+	"fmt"
+)
+
+type half struct {
+	v        uint16
+	unsigned bool
+}
+
+func (h *half) String() string { return fmt.Sprintf("%d", h.v) }
+
+func (h *half) Size() int32 { return 2 }
+
+func (h *half) RegSizeAlign() bool { return false }
+
+// Half creates a new halfword reference of a halfword constant.
+func Half(h uint16, unsigned bool) Ref { return &half{h, unsigned} }
@@ -62,11 +62,8 @@ func (p *datPool) declare(lib *link.Pkg) {
 	nfmt := fmt.Sprintf(":dat_%%0%dd", ndigit)
 	for i, d := range p.dat {
 		d.name = fmt.Sprintf(nfmt, i)
-		align := uint32(0)
-		if d.regSizeAlign {
-			align = regSize
-		}
-		v := link.NewVar(align)
+		align := alignOf(d.unitSize, d.regSizeAlign)
+		v := link.NewVar(uint32(align))
 		v.Write(d.bs)
 
 		lib.DeclareVar(d.name)
@@ -4,19 +4,19 @@ package codegen
 type HeapSym struct {
 	pkg, name    string
 	size         int32
-	u8           bool
+	unsigned     bool
 	regSizeAlign bool
 }
 
 // NewHeapSym creates a new heap var symbol.
 func NewHeapSym(
-	pkg, name string, size int32, u8, regSizeAlign bool,
+	pkg, name string, size int32, unsigned, regSizeAlign bool,
 ) *HeapSym {
 	return &HeapSym{
 		pkg:          pkg,
 		name:         name,
 		size:         size,
-		u8:           u8,
+		unsigned:     unsigned,
 		regSizeAlign: regSizeAlign,
 	}
 }
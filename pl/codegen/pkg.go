@@ -44,9 +44,9 @@ func (p *Pkg) NewFunc(name string, pos *lexing.Pos, sig *FuncSig) *Func {
 
 // NewGlobalVar creates a new global variable reference.
 func (p *Pkg) NewGlobalVar(
-	size int32, name string, u8, regSizeAlign bool,
+	size int32, name string, unsigned, regSizeAlign bool,
 ) Ref {
-	ret := NewHeapSym(p.path, name, size, u8, regSizeAlign)
+	ret := NewHeapSym(p.path, name, size, unsigned, regSizeAlign)
 	p.lib.DeclareVar(ret.name)
 	p.vars = append(p.vars, ret)
 	return ret
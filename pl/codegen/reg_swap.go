@@ -25,6 +25,8 @@ func saveArg(b *Block, reg uint32, v *Var) {
 	case 0:
 	case 1:
 		b.inst(asm.sb(reg, _sp, -v.Offset))
+	case 2:
+		b.inst(asm.sh(reg, _sp, -v.Offset))
 	case regSize:
 		b.inst(asm.sw(reg, _sp, -v.Offset))
 	default:
@@ -36,11 +38,17 @@ func loadArg(b *Block, reg uint32, v *Var) {
 	switch v.size {
 	case 0:
 	case 1:
-		if !v.U8 {
+		if !v.Unsigned {
 			b.inst(asm.lb(reg, _sp, -v.Offset))
 		} else {
 			b.inst(asm.lbu(reg, _sp, -v.Offset))
 		}
+	case 2:
+		if !v.Unsigned {
+			b.inst(asm.lh(reg, _sp, -v.Offset))
+		} else {
+			b.inst(asm.lhu(reg, _sp, -v.Offset))
+		}
 	case regSize:
 		b.inst(asm.lw(reg, _sp, -v.Offset))
 	default:
@@ -53,6 +61,8 @@ func saveVar(b *Block, reg uint32, v *Var) {
 	case 0:
 	case 1:
 		b.inst(asm.sb(reg, _sp, *b.frameSize-v.Offset))
+	case 2:
+		b.inst(asm.sh(reg, _sp, *b.frameSize-v.Offset))
 	case regSize:
 		b.inst(asm.sw(reg, _sp, *b.frameSize-v.Offset))
 	default:
@@ -64,11 +74,17 @@ func loadVar(b *Block, reg uint32, v *Var) {
 	switch v.size {
 	case 0: // do nothing
 	case 1:
-		if !v.U8 {
+		if !v.Unsigned {
 			b.inst(asm.lb(reg, _sp, *b.frameSize-v.Offset))
 		} else {
 			b.inst(asm.lbu(reg, _sp, *b.frameSize-v.Offset))
 		}
+	case 2:
+		if !v.Unsigned {
+			b.inst(asm.lh(reg, _sp, *b.frameSize-v.Offset))
+		} else {
+			b.inst(asm.lhu(reg, _sp, *b.frameSize-v.Offset))
+		}
 	default:
 		b.inst(asm.lw(reg, _sp, *b.frameSize-v.Offset))
 	}
@@ -90,6 +106,8 @@ func saveRef(b *Block, reg uint32, r Ref, tmpReg uint32) {
 		loadRef(b, tmpReg, r.base)
 		if r.size == 1 {
 			b.inst(asm.sb(reg, tmpReg, r.offset))
+		} else if r.size == 2 {
+			b.inst(asm.sh(reg, tmpReg, r.offset))
 		} else if r.size == regSize && r.regSizeAlign {
 			b.inst(asm.sw(reg, tmpReg, r.offset))
 		} else {
@@ -102,6 +120,8 @@ func saveRef(b *Block, reg uint32, r Ref, tmpReg uint32) {
 		loadSym(b, tmpReg, r.pkg, r.name)
 		if r.size == 1 {
 			b.inst(asm.sb(reg, tmpReg, 0))
+		} else if r.size == 2 {
+			b.inst(asm.sh(reg, tmpReg, 0))
 		} else if r.size == regSize {
 			b.inst(asm.sw(reg, tmpReg, 0))
 		} else {
@@ -109,7 +129,7 @@ func saveRef(b *Block, reg uint32, r Ref, tmpReg uint32) {
 		}
 	case *FuncPtr:
 		saveRef(b, reg, r.Ref, tmpReg)
-	case *number, *byt:
+	case *number, *byt, *half:
 		panic("constant references are read only")
 	default:
 		panic("not implemented")
@@ -151,6 +171,12 @@ func loadRef(b *Block, reg uint32, r Ref) {
 				b.inst(asm.ori(reg, reg, uint32(int32(bt))&0xffff))
 			}
 		}
+	case *half:
+		if r.unsigned {
+			loadUint32(b, reg, uint32(r.v))
+		} else {
+			loadUint32(b, reg, uint32(int32(int16(r.v))))
+		}
 	case *Func:
 		loadSym(b, reg, r.pkg, r.name)
 	case *FuncSym:
@@ -164,11 +190,17 @@ func loadRef(b *Block, reg uint32, r Ref) {
 
 		loadRef(b, reg, r.base)
 		if r.size == 1 {
-			if r.u8 {
+			if r.unsigned {
 				b.inst(asm.lbu(reg, reg, r.offset))
 			} else {
 				b.inst(asm.lb(reg, reg, r.offset))
 			}
+		} else if r.size == 2 {
+			if r.unsigned {
+				b.inst(asm.lhu(reg, reg, r.offset))
+			} else {
+				b.inst(asm.lh(reg, reg, r.offset))
+			}
 		} else if r.size == regSize && r.regSizeAlign {
 			b.inst(asm.lw(reg, reg, r.offset))
 		} else if !r.regSizeAlign {
@@ -182,11 +214,17 @@ func loadRef(b *Block, reg uint32, r Ref) {
 		}
 		loadSym(b, reg, r.pkg, r.name)
 		if r.size == 1 {
-			if r.u8 {
+			if r.unsigned {
 				b.inst(asm.lb(reg, reg, 0))
 			} else {
 				b.inst(asm.lbu(reg, reg, 0))
 			}
+		} else if r.size == 2 {
+			if r.unsigned {
+				b.inst(asm.lhu(reg, reg, 0))
+			} else {
+				b.inst(asm.lh(reg, reg, 0))
+			}
 		} else if r.size == regSize {
 			b.inst(asm.lw(reg, reg, 0))
 		} else {
@@ -200,19 +238,19 @@ func loadRef(b *Block, reg uint32, r Ref) {
 func canViaReg(r Ref) bool {
 	switch r := r.(type) {
 	case *Var:
-		return r.size <= 1 || r.size == regSize
+		return r.size <= 2 || r.size == regSize
 	case *number:
 		return true
-	case *byt:
+	case *byt, *half:
 		return true
 	case *Func:
 		return true
 	case *FuncSym:
 		return true
 	case *AddrRef:
-		return r.size <= 1 || (r.size == regSize && r.regSizeAlign)
+		return r.size <= 2 || (r.size == regSize && r.regSizeAlign)
 	case *HeapSym:
-		return r.size <= 1 || r.size == regSize
+		return r.size <= 2 || r.size == regSize
 	}
 	return false
 }
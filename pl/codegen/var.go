@@ -11,7 +11,7 @@ type Var struct {
 	// after SP shift, the variable is saved at [SP+framesize-offset]
 	Offset int32
 
-	U8 bool // if this var is a unsigned byte
+	Unsigned bool // if this var is an unsigned byte or halfword
 
 	// reg is the register allocated
 	// valid values are in range [1, 4] for normal values
@@ -20,11 +20,11 @@ type Var struct {
 }
 
 // NewVar creates a new variable.
-func NewVar(n int32, name string, u8, regSizeAlign bool) *Var {
+func NewVar(n int32, name string, unsigned, regSizeAlign bool) *Var {
 	return &Var{
 		name:         name,
 		size:         n,
-		U8:           u8,
+		Unsigned:     unsigned,
 		regSizeAlign: regSizeAlign,
 	}
 }
@@ -45,5 +45,5 @@ func (v *Var) RegSizeAlign() bool { return v.regSizeAlign }
 // CanViaReg tells if the variables can be saved and loaded via
 // a register.
 func (v *Var) CanViaReg() bool {
-	return v.size == 1 || v.size == 4
+	return v.size == 1 || v.size == 2 || v.size == 4
 }
@@ -34,7 +34,7 @@ func zeroRef(g *gener, b *Block, r Ref) {
 
 		switch {
 		case r.size == 0:
-		case r.size == 1:
+		case r.size == 1, r.size == 2:
 			saveVar(b, 0, r)
 		case r.size == regSize && r.RegSizeAlign():
 			saveVar(b, 0, r)
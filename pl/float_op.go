@@ -0,0 +1,33 @@
+package pl
+
+import (
+	"shanhu.io/smlvm/pl/types"
+)
+
+func unaryOpFloat(b *builder, op string, B *ref) *ref {
+	switch op {
+	case "+":
+		return B
+	case "-":
+		ret := b.newTemp(B.Type())
+		b.b.Arith(ret.IR(), nil, "fneg", B.IR())
+		return ret
+	}
+	panic("bug")
+}
+
+// binaryOpFloat builds float ops. Dividing by zero does not panic;
+// it results in an infinity or a NaN.
+func binaryOpFloat(b *builder, op string, A, B *ref) *ref {
+	switch op {
+	case "+", "-", "*", "/":
+		ret := b.newTemp(types.Float32)
+		b.b.Arith(ret.IR(), A.IR(), "f"+op, B.IR())
+		return ret
+	case "==", "!=", ">", "<", ">=", "<=":
+		ret := b.newTemp(types.Bool)
+		b.b.Arith(ret.IR(), A.IR(), "f"+op, B.IR())
+		return ret
+	}
+	panic("bug")
+}
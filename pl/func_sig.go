@@ -9,7 +9,7 @@ func makeArg(t *types.Arg) *codegen.FuncArg {
 	return &codegen.FuncArg{
 		Name:         t.Name,
 		Size:         t.Size(),
-		U8:           types.IsBasic(t.T, types.Uint8),
+		Unsigned:     types.IsUnsigned(t.T),
 		RegSizeAlign: t.RegSizeAlign(),
 	}
 }
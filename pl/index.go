@@ -10,6 +10,8 @@ func arrayElementSize(t types.T) int32 {
 	ret := t.Size()
 	if t.RegSizeAlign() {
 		return types.RegSizeAlignUp(ret)
+	} else if types.HalfAlign(t) {
+		return ret + ret%2
 	}
 	return ret
 }
@@ -126,11 +128,11 @@ func buildArrayGet(b *builder, expr *tast.IndexExpr, array *ref) *ref {
 	size := et.Size()
 
 	retIR := codegen.NewAddrRef(
-		addr,             // base address
-		size,             // size
-		0,                // dynamic offset; precalculated
-		types.IsByte(et), // isByte
-		true,             // isAlign
+		addr,                 // base address
+		size,                 // size
+		0,                    // dynamic offset; precalculated
+		types.IsUnsigned(et), // unsigned
+		true,                 // isAlign
 	)
 	return newAddressableRef(et, retIR)
 }
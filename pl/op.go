@@ -24,6 +24,8 @@ func buildUnaryOpExpr(b *builder, expr *tast.OpExpr) *ref {
 		panic("bug")
 	} else if types.IsInteger(btyp) {
 		return unaryOpInt(b, op, B)
+	} else if types.IsBasic(btyp, types.Float32) {
+		return unaryOpFloat(b, op, B)
 	} else if types.IsBasic(btyp, types.Bool) {
 		return unaryOpBool(b, op, B)
 	}
@@ -58,10 +60,12 @@ func buildBinaryOpExpr(b *builder, expr *tast.OpExpr) *ref {
 
 	if ok, t := types.SameBasic(atyp, btyp); ok {
 		switch t {
-		case types.Int, types.Int8:
+		case types.Int, types.Int8, types.Int16:
 			return binaryOpInt(b, op, A, B, t)
-		case types.Uint, types.Uint8:
+		case types.Uint, types.Uint8, types.Uint16:
 			return binaryOpUint(b, op, A, B, t)
+		case types.Float32:
+			return binaryOpFloat(b, op, A, B)
 		case types.Bool:
 			return binaryOpBool(b, op, A, B)
 		}
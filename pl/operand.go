@@ -2,6 +2,7 @@ package pl
 
 import (
 	"fmt"
+	"math"
 
 	"shanhu.io/smlvm/pl/codegen"
 	"shanhu.io/smlvm/pl/tast"
@@ -14,6 +15,11 @@ func buildConst(b *builder, c *tast.Const) *ref {
 		return newRef(c.T, nil)
 	}
 
+	if c.T == types.Float32 {
+		v := c.ConstValue.(float32)
+		return newRef(c.T, codegen.Num(math.Float32bits(v)))
+	}
+
 	if t, ok := c.T.(types.Basic); ok {
 		v := c.ConstValue.(int64)
 		return newRef(c.T, constNumIr(v, t))
@@ -29,10 +35,12 @@ func buildConst(b *builder, c *tast.Const) *ref {
 	if t, ok := c.T.(*types.Slice); ok {
 		if bt, ok := t.T.(types.Basic); ok {
 			switch bt {
-			case types.Int, types.Uint, types.Int8, types.Uint8, types.Bool:
+			case types.Int, types.Uint, types.Int8, types.Uint8,
+				types.Int16, types.Uint16, types.Bool:
 				bs := c.ConstValue.([]byte)
 				ret := b.newTemp(t)
-				ref := b.p.NewHeapDat(bs, bt.Size(), bt.RegSizeAlign())
+				unit := arrayElementSize(bt)
+				ref := b.p.NewHeapDat(bs, unit, bt.RegSizeAlign())
 				b.b.Arith(ret.IR(), nil, "makeDat", ref)
 				return ret
 			default:
@@ -51,7 +59,7 @@ func buildField(b *builder, this codegen.Ref, field *types.Field) *ref {
 		this,
 		field.T.Size(),
 		field.Offset(),
-		types.IsByte(field.T),
+		types.IsUnsigned(field.T),
 		true,
 	)
 	return newAddressableRef(field.T, retIR)
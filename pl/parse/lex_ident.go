@@ -8,9 +8,33 @@ func isLetter(r rune) bool {
 	return r == '_' || lexing.IsLetter(r)
 }
 
-func lexNumber(x *lexing.Lexer) *lexing.Token {
-	// TODO: lex floating point as well
+func lexDigits(x *lexing.Lexer) {
+	for lexing.IsDigit(x.Rune()) {
+		x.Next()
+	}
+}
 
+// lexFloat lexes the fraction and the exponent of a float literal, after
+// the integer part.
+func lexFloat(x *lexing.Lexer) *lexing.Token {
+	if x.See('.') {
+		x.Next()
+		lexDigits(x)
+	}
+	if x.See('e') || x.See('E') {
+		x.Next()
+		if x.See('+') || x.See('-') {
+			x.Next()
+		}
+		if !lexing.IsDigit(x.Rune()) {
+			x.Errorf("missing exponent in float literal")
+		}
+		lexDigits(x)
+	}
+	return x.MakeToken(Float)
+}
+
+func lexNumber(x *lexing.Lexer) *lexing.Token {
 	start := x.Rune()
 	if !lexing.IsDigit(start) {
 		panic("not starting with a number")
@@ -26,8 +50,9 @@ func lexNumber(x *lexing.Lexer) *lexing.Token {
 			x.Next()
 		}
 	} else {
-		for lexing.IsDigit(x.Rune()) {
-			x.Next()
+		lexDigits(x)
+		if x.See('.') || x.See('e') || x.See('E') {
+			return lexFloat(x)
 		}
 	}
 	return x.MakeToken(Int)
@@ -61,6 +61,10 @@ func buildArrayLit(b *builder, lit *ast.ArrayLiteral) tast.Expr {
 					var bs [4]byte
 					binary.LittleEndian.PutUint32(bs[:], uint32(v))
 					buf.Write(bs[:])
+				case types.Int16, types.Uint16:
+					var bs [2]byte
+					binary.LittleEndian.PutUint16(bs[:], uint16(v))
+					buf.Write(bs[:])
 				case types.Int8, types.Uint8:
 					buf.Write([]byte{byte(v)})
 				default:
@@ -104,8 +104,14 @@ func opAssign(b *builder, dest, src tast.Expr, op *lexing.Token) tast.Stmt {
 
 	if ok, t := types.SameBasic(destType, srcType); ok {
 		switch t {
-		case types.Int, types.Int8, types.Uint, types.Uint8:
+		case types.Int, types.Int8, types.Int16,
+			types.Uint, types.Uint8, types.Uint16:
 			return &tast.AssignStmt{dest, op, src}
+		case types.Float32:
+			switch opLit {
+			case "+", "-", "*", "/":
+				return &tast.AssignStmt{dest, op, src}
+			}
 		}
 	}
 
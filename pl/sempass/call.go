@@ -100,6 +100,40 @@ func buildCallMake(b *builder, expr *ast.CallExpr, f tast.Expr) tast.Expr {
 	return nil
 }
 
+// buildCallMulHigh builds mulHigh() or mulHighU(), which returns the
+// high 32 bits of the 64-bit product of two integers of type t.
+func buildCallMulHigh(
+	b *builder, expr *ast.CallExpr, f tast.Expr, t types.Basic,
+) tast.Expr {
+	name := f.R().T.(*types.BuiltInFunc).Name
+	args := buildExprList(b, expr.Args)
+	if args == nil {
+		return nil
+	}
+	argsList, ok := tast.MakeExprList(args)
+	if !ok || argsList.Len() != 2 {
+		b.Errorf(expr.Lparen.Pos, "%s() takes two arguments", name)
+		return nil
+	}
+
+	callArgs := tast.NewExprList()
+	for i, arg := range argsList.Exprs {
+		pos := ast.ExprPos(expr.Args.Exprs[i])
+		argType := arg.R().T
+		if v, ok := types.NumConst(argType); ok {
+			arg = constCast(b, pos, v, arg, t)
+			if arg == nil {
+				return nil
+			}
+		} else if !types.IsBasic(argType, t) {
+			b.Errorf(pos, "%s() takes %s, got %s", name, t, argType)
+			return nil
+		}
+		callArgs.Append(arg)
+	}
+	return &tast.CallExpr{f, callArgs, tast.NewRef(t)}
+}
+
 func buildCallExpr(b *builder, expr *ast.CallExpr) tast.Expr {
 	hold := b.lhsSwap(false)
 	defer b.lhsRestore(hold)
@@ -126,6 +160,10 @@ func buildCallExpr(b *builder, expr *ast.CallExpr) tast.Expr {
 			return buildCallLen(b, expr, f)
 		case "make":
 			return buildCallMake(b, expr, f)
+		case "mulHigh":
+			return buildCallMulHigh(b, expr, f, types.Int)
+		case "mulHighU":
+			return buildCallMulHigh(b, expr, f, types.Uint)
 		}
 		b.Errorf(pos, "builtin %s() not implemented", builtin.Name)
 		return nil
@@ -23,6 +23,20 @@ func regSizeCastable(to, from types.T) bool {
 	return false
 }
 
+// floatCastable checks if it is a conversion into float from an
+// integer or a float, or a conversion from float into int. A float does
+// not convert into other integer types.
+func floatCastable(to, from types.T) bool {
+	if types.IsBasic(to, types.Float32) {
+		return types.IsInteger(from) ||
+			types.IsBasic(from, types.Float32)
+	}
+	if types.IsBasic(from, types.Float32) {
+		return types.IsBasic(to, types.Int)
+	}
+	return false
+}
+
 func buildCast(b *builder, expr *ast.CallExpr, t types.T) tast.Expr {
 	pos := expr.Lparen.Pos
 
@@ -39,7 +53,7 @@ func buildCast(b *builder, expr *ast.CallExpr, t types.T) tast.Expr {
 
 	srcType := ref.T
 	if c, ok := srcType.(*types.Const); ok {
-		if v, ok := types.NumConst(srcType); ok && types.IsInteger(t) {
+		if v, ok := types.NumConst(srcType); ok && types.IsNumeric(t) {
 			return constCast(b, pos, v, args, t)
 		}
 		srcType = c.Type // using the underlying type
@@ -51,6 +65,9 @@ func buildCast(b *builder, expr *ast.CallExpr, t types.T) tast.Expr {
 	if regSizeCastable(t, srcType) {
 		return tast.NewCast(args, t)
 	}
+	if floatCastable(t, srcType) {
+		return tast.NewCast(args, t)
+	}
 
 	b.Errorf(pos, "cannot convert from %s to %s", srcType, t)
 	return nil
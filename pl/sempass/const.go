@@ -12,6 +12,9 @@ func constCast(
 	if types.IsInteger(to) && types.InRange(v, to) {
 		return tast.NewCast(from, to)
 	}
+	if types.IsBasic(to, types.Float32) {
+		return tast.NewCast(from, to)
+	}
 	b.Errorf(pos, "cannot cast %d to %s", v, to)
 	return nil
 }
@@ -0,0 +1,38 @@
+package sempass
+
+import (
+	"shanhu.io/smlvm/lexing"
+	"shanhu.io/smlvm/pl/tast"
+	"shanhu.io/smlvm/pl/types"
+)
+
+func unaryOpFloat(b *builder, opTok *lexing.Token, B tast.Expr) tast.Expr {
+	op := opTok.Lit
+	switch op {
+	case "+":
+		return B
+	case "-":
+		t := B.R().T
+		return &tast.OpExpr{nil, opTok, B, tast.NewRef(t)}
+	}
+
+	b.Errorf(opTok.Pos, "invalid operation: %q on %s", op, B)
+	return nil
+}
+
+func binaryOpFloat(
+	b *builder, opTok *lexing.Token, A, B tast.Expr, t types.T,
+) tast.Expr {
+	op := opTok.Lit
+	switch op {
+	case "+", "-", "*", "/":
+		r := tast.NewRef(t)
+		return &tast.OpExpr{A, opTok, B, r}
+	case "==", "!=", ">", "<", ">=", "<=":
+		r := tast.NewRef(types.Bool)
+		return &tast.OpExpr{A, opTok, B, r}
+	}
+
+	b.Errorf(opTok.Pos, "%q on floats", op)
+	return nil
+}
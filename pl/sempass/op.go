@@ -70,6 +70,8 @@ func buildUnaryOpExpr(b *builder, expr *ast.OpExpr) tast.Expr {
 		return unaryOpConst(b, opTok, B)
 	} else if types.IsInteger(btyp) {
 		return unaryOpInt(b, opTok, B)
+	} else if types.IsBasic(btyp, types.Float32) {
+		return unaryOpFloat(b, opTok, B)
 	} else if types.IsBasic(btyp, types.Bool) {
 		return unaryOpBool(b, opTok, B)
 	}
@@ -156,13 +158,13 @@ func buildBinaryOpExpr(b *builder, expr *ast.OpExpr) tast.Expr {
 
 	if ok, t := types.SameBasic(atyp, btyp); ok {
 		switch t {
-		case types.Int, types.Int8, types.Uint, types.Uint8:
+		case types.Int, types.Int8, types.Int16,
+			types.Uint, types.Uint8, types.Uint16:
 			return binaryOpInt(b, opTok, A, B, t)
 		case types.Bool:
 			return binaryOpBool(b, opTok, A, B)
 		case types.Float32:
-			b.Errorf(opPos, "floating point operations not implemented")
-			return nil
+			return binaryOpFloat(b, opTok, A, B, t)
 		}
 	}
 
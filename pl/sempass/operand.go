@@ -30,6 +30,16 @@ func buildInt(b *builder, op *lexing.Token) tast.Expr {
 	return &tast.Const{ref}
 }
 
+func buildFloat(b *builder, op *lexing.Token) tast.Expr {
+	v, e := strconv.ParseFloat(op.Lit, 32)
+	if e != nil {
+		b.Errorf(op.Pos, "invalid float: %s", e)
+		return nil
+	}
+	ref := tast.NewConstRef(types.Float32, float32(v))
+	return &tast.Const{ref}
+}
+
 func buildChar(b *builder, op *lexing.Token) tast.Expr {
 	v, e := strconv.Unquote(op.Lit)
 	if e != nil {
@@ -137,6 +147,8 @@ func buildOperand(b *builder, op *ast.Operand) tast.Expr {
 	switch op.Token.Type {
 	case parse.Int:
 		return buildInt(b, op.Token)
+	case parse.Float:
+		return buildFloat(b, op.Token)
 	case parse.Char:
 		return buildChar(b, op.Token)
 	case parse.String:
@@ -17,6 +17,19 @@ func TestSingleFileBad(t *testing.T) {
 	}
 
 	o("") // no main
+	o("func main() { a := 1.5; a %= 2 }")
+	o("func main() { a := 1.5; b := a & 1.5; _ := b }")
+	o("func main() { var a float; var b int = a; _ := b }")
+	o("func main() { var a uint = uint(1.5); _ := a }")
+	o("func main() { var a float; b := uint(a); _ := b }")
+	o("func main() { a := 1e; _ := a }")
+	o("func main() { var a int16 = 32768; _ := a }")
+	o("func main() { var a uint16 = -1; _ := a }")
+	o("func main() { var a int16; var b int = a; _ := b }")
+	o("func main() { var a int16; var b uint16; c := a + b; _ := c }")
+	o("func main() { a := mulHigh(1); _ := a }")
+	o("func main() { a := mulHighU(-1, 2); _ := a }")
+	o("func main() { a := mulHigh(uint(1), 2); _ := a }")
 	o(`func a() {}; func a() {}; func main {}`)
 	o(`struct A { b int; b int }; func main {}`)
 	o(`struct A { a A }; func main() {}`)
@@ -3,6 +3,7 @@ package pl
 import (
 	"testing"
 
+	"bytes"
 	"errors"
 	"strings"
 
@@ -273,4 +274,122 @@ func TestSingleFile(t *testing.T) {
 			printInt(int(a[0]))
 		}
 		func main() { f(0, 0, 0) }`, "0")
+
+	// atomic builtins
+	o(` func main() {
+			var a int
+			printInt(atomicAdd(&a, 3)); printInt(atomicSwap(&a, 7))
+			if compareAndSwap(&a, 3, 4) { panic() }
+			if !compareAndSwap(&a, 7, 5) { panic() }
+			printInt(a)
+		}`, "3\n3\n5")
+
+	// floats
+	o("func main() { printFloat(1.5) }", "+1.500000e+000")
+	o("func main() { printFloat(-0.25e-2) }", "-2.500000e-003")
+	o("func main() { printFloat(0) }", "+0.000000e+000")
+	o("func main() { printFloat(3e38) }", "+3.000000e+038")
+	o("func main() { printFloat(9.9999999) }", "+1.000000e+001")
+	o(` func main() {
+			var a float = 3
+			b := a * 2.5 + 1; printFloat(b)
+			b /= 2; b -= 0.75; printFloat(-b)
+			printInt(int(b)); printFloat(float(7) / 2)
+			printInt(int(-2.75)); printFloat(float(int(1e10)))
+		}`, "+8.500000e+000\n-3.500000e+000\n3\n"+
+		"+3.500000e+000\n-2\n+2.147484e+009")
+	o(` func main() {
+			var a uint = 3; printFloat(float(a))
+			a = 0xffffffff; printFloat(float(a))
+			a = 0x80000001; printFloat(float(a))
+			var b byte = 200; printFloat(float(b))
+			var c int8 = -5; printFloat(float(c))
+		}`, "+3.000000e+000\n+4.294967e+009\n+2.147484e+009\n"+
+		"+2.000000e+002\n-5.000000e+000")
+	o(` func main() {
+			var z float
+			inf := 1 / z; printFloat(inf); printFloat(-inf)
+			nan := z / z; printFloat(nan)
+			if nan == nan || !(nan != nan) || nan < 1 || nan >= 1 { panic() }
+			if !(inf > 1e38) || -inf >= 0 { panic() }
+			a, b := 1.5, 2.5
+			if !(a < b && a <= b && b > a && b >= a && a != b) { panic() }
+			if a == b || a > b || !(a <= 1.5) { panic() }
+		}`, "+Inf\n-Inf\nNaN")
+
+	// halfwords
+	o(` func main() {
+			var a int16 = 32767; a++; printInt(int(a))
+			var b uint16 = 65535; b += 2; printInt(int(b))
+			c := uint16(a); printInt(int(c))
+			x := -70000; d := int16(x); printInt(int(d))
+			e := int16(int8(-3)) * 1000; printInt(int(e))
+			if a > 0 || c < 32768 { panic() }
+		}`, "-32768\n1\n32768\n-4464\n-3000")
+	o(` struct P { a int8; b int16; c uint16 }
+		var g uint16
+		func f(x int16, y uint16) int { return int(x) + int(y) }
+		func main() {
+			var p P; p.a = 1; p.b = -2; p.c = 40000
+			q := &p; q.b--; printInt(f(q.b, p.c))
+			var arr [4]uint16
+			for i := 0; i < 4; i++ { arr[i] = uint16(i * 30000) }
+			printInt(int(arr[3])); g = arr[2]; printInt(int(g))
+			s := []int16{-1, 300, -32768}
+			printInt(int(s[0]) + int(s[1]) + int(s[2]))
+		}`, "39997\n24464\n60000\n-32469")
+	o(` struct H { a int8; b int16; c int8 }
+		struct W { a int8; b int16; c int }
+		func main() {
+			var a [3]int16; var h H; var hs [2]H; var ws [2]W
+			printUint(uint(&a[1]) - uint(&a[0]))
+			printUint(uint(&h.b) - uint(&h))
+			printUint(uint(&h.c) - uint(&h))
+			printUint(uint(&hs[1]) - uint(&hs[0]))
+			printUint(uint(&ws[1]) - uint(&ws[0]))
+			var c char; var x int16 = -5; var y int8; var z uint16 = 7
+			printInt(int(c) + int(x) + int(y) + int(z))
+			hs[1].b = -300; printInt(int(hs[1].b))
+			s := []uint16{1, 65535, 2}; printUint(uint(s[1] + s[2]))
+		}`, "2\n2\n4\n6\n8\n2\n-300\n1")
+
+	// high-word multiply
+	o(` func main() {
+			printInt(mulHigh(-2, 0x40000000))
+			printUint(mulHighU(0xffffffff, 0xffffffff))
+			a := 100000; printInt(mulHigh(a, a))
+		}`, "-1\n4294967294\n2")
+}
+
+func TestReadChar(t *testing.T) {
+	bs, es, _ := CompileSingle("main.g", `
+		func main() {
+			for {
+				c := readChar()
+				if c < 0 { break }
+				if c >= 97 && c <= 122 { c -= 32 }
+				printChar(char(c))
+			}
+		}`, false)
+	if es != nil {
+		for _, err := range es {
+			t.Log(err)
+		}
+		t.Fatal("compile failed")
+	}
+
+	out := new(bytes.Buffer)
+	m := arch.NewMachine(&arch.Config{
+		Output: out,
+		Input:  strings.NewReader("hello\n"),
+	})
+	if err := m.LoadImageBytes(bs); err != nil {
+		t.Fatal(err)
+	}
+	if _, e := m.Run(1000000); !arch.IsHalt(e) {
+		t.Fatalf("did not halt gracefully: %v", e)
+	}
+	if got := out.String(); got != "HELLO\n" {
+		t.Errorf("got %q, want %q", got, "HELLO\n")
+	}
 }
@@ -15,6 +15,8 @@ func (t *Array) Size() int32 {
 	size := t.T.Size()
 	if t.RegSizeAlign() {
 		size = RegSizeAlignUp(size)
+	} else if HalfAlign(t.T) {
+		size += size % 2
 	}
 	return size * t.N
 }
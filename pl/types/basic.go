@@ -13,6 +13,8 @@ const (
 	Uint
 	Int8
 	Uint8
+	Int16
+	Uint16
 	Float32
 	Bool
 )
@@ -46,12 +48,17 @@ func IsInteger(t T) bool {
 		return false
 	}
 	switch code {
-	case Int, Uint, Int8, Uint8:
+	case Int, Uint, Int8, Uint8, Int16, Uint16:
 		return true
 	}
 	return false
 }
 
+// IsNumeric checks if a type is an integer type or Float32
+func IsNumeric(t T) bool {
+	return IsInteger(t) || IsBasic(t, Float32)
+}
+
 // IsSigned checks if a type is a signed integer type
 func IsSigned(t T) bool {
 	code, ok := t.(Basic)
@@ -59,7 +66,7 @@ func IsSigned(t T) bool {
 		return false
 	}
 	switch code {
-	case Int, Int8:
+	case Int, Int8, Int16:
 		return true
 	}
 	return false
@@ -72,7 +79,7 @@ func IsUnsigned(t T) bool {
 		return false
 	}
 	switch code {
-	case Uint, Uint8:
+	case Uint, Uint8, Uint16:
 		return true
 	}
 	return false
@@ -116,6 +123,8 @@ func (t Basic) Size() int32 {
 		return 4
 	case Int8, Uint8:
 		return 1
+	case Int16, Uint16:
+		return 2
 	case Float32:
 		return 4
 	case Bool:
@@ -136,21 +145,26 @@ func (t Basic) String() string {
 		return "int8"
 	case Uint8:
 		return "uint8"
+	case Int16:
+		return "int16"
+	case Uint16:
+		return "uint16"
 	case Bool:
 		return "bool"
 	case Float32:
-		return "float32"
+		return "float"
 	default:
 		panic(fmt.Errorf("invalid basic type %d", t))
 	}
 }
 
-// RegSizeAlign checks if the type is word aligned.
+// RegSizeAlign checks if the type is word aligned. Halfword types are
+// halfword aligned; see HalfAlign.
 func (t Basic) RegSizeAlign() bool {
 	switch t {
 	case Int, Uint, Float32:
 		return true
-	case Int8, Uint8, Bool:
+	case Int8, Uint8, Int16, Uint16, Bool:
 		return false
 	default:
 		panic(fmt.Errorf("invalid basic type %d", t))
@@ -13,6 +13,20 @@ func RegSizeAlignUp(size int32) int32 {
 	return size + arch.RegSize - mod
 }
 
+// HalfAlign checks if the type is halfword aligned but not word aligned,
+// i.e. it holds halfwords but no words. Such types have even sizes.
+func HalfAlign(t T) bool {
+	switch t := t.(type) {
+	case Basic:
+		return t == Int16 || t == Uint16
+	case *Array:
+		return HalfAlign(t.T)
+	case *Struct:
+		return t.halfAlign && !t.regSizeAlign
+	}
+	return false
+}
+
 // Pointer is a pointer type
 type Pointer struct{ T T } // a pointer type
 
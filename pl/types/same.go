@@ -20,6 +20,10 @@ func InRange(v int64, t T) bool {
 		return v >= math.MinInt8 && v <= math.MaxInt8
 	case Uint8:
 		return v >= 0 && v <= math.MaxUint8
+	case Int16:
+		return v >= math.MinInt16 && v <= math.MaxInt16
+	case Uint16:
+		return v >= 0 && v <= math.MaxUint16
 	}
 	return false
 }
@@ -28,6 +32,9 @@ func InRange(v int64, t T) bool {
 func CanAssign(left, right T) bool {
 	if c, ok := right.(*Const); ok {
 		if _, ok := c.Type.(Number); ok {
+			if IsBasic(left, Float32) {
+				return true
+			}
 			return InRange(c.Value.(int64), left)
 		}
 
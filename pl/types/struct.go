@@ -24,6 +24,7 @@ type Struct struct {
 	name         string
 	size         int32
 	regSizeAlign bool
+	halfAlign    bool
 }
 
 // NewStruct constructs a new struct type.
@@ -42,13 +43,22 @@ func (t *Struct) AddField(f *Field) {
 	if f.T.RegSizeAlign() {
 		t.size = RegSizeAlignUp(t.size)
 		t.regSizeAlign = true
+	} else if HalfAlign(f.T) {
+		t.size += t.size % 2
+		t.halfAlign = true
 	}
 	f.offset = t.size
 	t.size += fsize
 }
 
-// Size returns the overall size of the structure type
-func (t *Struct) Size() int32 { return t.size }
+// Size returns the overall size of the structure type. A struct that
+// holds halfwords has an even size.
+func (t *Struct) Size() int32 {
+	if t.halfAlign {
+		return t.size + t.size%2
+	}
+	return t.size
+}
 
 // String returns the name of the structure type
 func (t *Struct) String() string { return t.name }
@@ -0,0 +1,173 @@
+package pl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"shanhu.io/smlvm/arch"
+	"shanhu.io/smlvm/arch/keyboard"
+)
+
+// vpcCallSrc calls a service with the call registers on the RPC page.
+const vpcCallSrc = `
+	struct vpcRegs {
+		control uint
+		service uint
+		reqAddr uint
+		reqLen uint
+		respAddr uint
+		respSize uint
+		code uint
+		respLen uint
+	}
+
+	func vpcCall(
+		ctrl, service uint, req, resp []byte,
+	) (int, int) {
+		r := (*vpcRegs)(uint(0x3000))
+		r.service = service
+		r.reqLen = uint(len(req))
+		if len(req) > 0 { r.reqAddr = uint(&req[0]) }
+		r.respSize = uint(len(resp))
+		if len(resp) > 0 { r.respAddr = uint(&resp[0]) }
+		r.control = ctrl
+		iocall()
+		return int(r.code), int(r.respLen)
+	}
+`
+
+// servicesSrc wraps the controls of the system service that list the
+// services and mute their messages.
+const servicesSrc = `
+	func listServices(buf []byte, f func(id uint, name []byte)) int {
+		code, n := vpcCall(2, 0, nil, buf)
+		if code != 0 { return code }
+		for i := 0; i < n; {
+			id := uint(buf[i]) | uint(buf[i+1]) << 8 |
+				uint(buf[i+2]) << 16 | uint(buf[i+3]) << 24
+			nameLen := int(buf[i+4])
+			i += 5
+			f(id, buf[i:i+nameLen])
+			i += nameLen
+		}
+		return 0
+	}
+
+	func enableService(id uint, on bool) int {
+		var req [5]byte
+		req[0] = byte(id); req[1] = byte(id >> 8)
+		req[2] = byte(id >> 16); req[3] = byte(id >> 24)
+		if on { req[4] = 1 }
+		code, _ := vpcCall(3, 0, req[:], nil)
+		return code
+	}
+`
+
+func TestServicesG(t *testing.T) {
+	const N = 100000
+	src := vpcCallSrc + servicesSrc + `
+		func printService(id uint, name []byte) {
+			for i := 0; i < len(name); i++ { printChar(char(name[i])) }
+			printUint(id)
+		}
+
+		func main() {
+			var buf [256]byte
+			if listServices(buf[:], printService) != 0 { panic() }
+			printInt(enableService(3, false))
+			printInt(enableService(3, true))
+			printInt(enableService(99, false))
+		}`
+	out, err := singleTestRun(t, src, N)
+	if err == errRunFailed {
+		return
+	}
+	if !arch.IsHalt(err) {
+		t.Fatalf("did not halt gracefully: %v", err)
+	}
+
+	want := "console1\nrand3\nclock4\ntimer7\n0\n0\n1"
+	if got := strings.TrimSpace(out); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// keyboardSrc polls the messages, and decodes the keyboard events.
+const keyboardSrc = `
+	func poll(buf []byte) (uint, int) {
+		code, n := vpcCall(1, 0, nil, buf)
+		if code != 0 { panic() }
+		return (*vpcRegs)(uint(0x3000)).service, n
+	}
+
+	func setKeyboardMode(mode byte) byte {
+		var req [2]byte
+		req[1] = mode
+		var resp [1]byte
+		code, _ := vpcCall(1, 9, req[:], resp[:])
+		if code != 0 { panic() }
+		return resp[0]
+	}
+
+	// printKeyEvent prints a key event as the kind, the modifiers and
+	// the key code, or a text event as the kind and the text.
+	func printKeyEvent(msg []byte) {
+		kind := msg[0]
+		printUint(uint(kind))
+		if kind == 3 {
+			for i := 1; i < len(msg); i++ { printChar(char(msg[i])) }
+			printChar('\n')
+			return
+		}
+		printUint(uint(msg[1]))
+		printUint(uint(msg[2]) | uint(msg[3]) << 8)
+	}
+`
+
+type nopScreen struct{}
+
+func (nopScreen) NeedUpdate() bool              { return false }
+func (nopScreen) UpdateText(m map[uint32]byte)  {}
+func (nopScreen) UpdateColor(m map[uint32]byte) {}
+
+func TestKeyboardG(t *testing.T) {
+	const N = 100000
+	src := vpcCallSrc + keyboardSrc + `
+		func main() {
+			printUint(uint(setKeyboardMode(3)))
+			var buf [64]byte
+			for i := 0; i < 3; i++ {
+				service, n := poll(buf[:])
+				if service != 9 { panic() }
+				printKeyEvent(buf[:n])
+			}
+		}`
+	bs, es, _ := CompileSingle("main.g", src, false)
+	if es != nil {
+		for _, err := range es {
+			t.Log(err)
+		}
+		t.Fatal("compile failed")
+	}
+
+	out := new(bytes.Buffer)
+	m := arch.NewMachine(&arch.Config{Output: out, Screen: nopScreen{}})
+	if err := m.LoadImageBytes(bs); err != nil {
+		t.Fatal(err)
+	}
+	if _, e := m.Run(N); !arch.IsSleep(e) {
+		t.Fatalf("want the program waiting for events, got %v", e)
+	}
+	m.KeyPress(keyboard.CodeUp, keyboard.ModShift, true)
+	m.KeyPress(keyboard.CodeUp, keyboard.ModShift, false)
+	m.TypeText("hi")
+	if _, e := m.Run(N); !arch.IsHalt(e) {
+		t.Fatalf("did not halt gracefully: %v", e)
+	}
+
+	want := "1\n1\n1\n256\n2\n1\n256\n3\nhi"
+	if got := strings.TrimSpace(out.String()); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
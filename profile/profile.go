@@ -0,0 +1,151 @@
+// Package profile writes sampled call stacks of simulated programs in
+// the pprof protocol buffer format, for viewing with go tool pprof.
+package profile
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// Frame is a frame of a sampled call stack.
+type Frame struct {
+	PC   uint32
+	Func string // empty when the function is unknown
+	File string
+	Line int // the line where the function starts
+}
+
+// Sample is a sampled call stack, with the innermost frame first.
+type Sample struct {
+	Stack  []*Frame
+	Count  int64 // number of times the stack is sampled
+	Cycles int64 // cycles attributed to the stack
+}
+
+// Profile is a set of sampled call stacks.
+type Profile struct {
+	Period  int64 // cycles between two samples
+	Samples []*Sample
+}
+
+type funcKey struct {
+	name string
+	file string
+}
+
+type encoder struct {
+	strs    map[string]int64
+	strList []string
+	funcs   map[funcKey]uint64
+	locs    map[uint32]uint64
+
+	buf *protoBuf
+}
+
+func newEncoder() *encoder {
+	ret := &encoder{
+		strs:  make(map[string]int64),
+		funcs: make(map[funcKey]uint64),
+		locs:  make(map[uint32]uint64),
+		buf:   new(protoBuf),
+	}
+	ret.str("") // string table starts with an empty string
+	return ret
+}
+
+func (e *encoder) str(s string) int64 {
+	if i, found := e.strs[s]; found {
+		return i
+	}
+	i := int64(len(e.strList))
+	e.strs[s] = i
+	e.strList = append(e.strList, s)
+	return i
+}
+
+func (e *encoder) valueType(field int, typ, unit string) {
+	m := new(protoBuf)
+	m.int64(1, e.str(typ))
+	m.int64(2, e.str(unit))
+	e.buf.message(field, m)
+}
+
+func (e *encoder) function(f *Frame) uint64 {
+	k := funcKey{f.Func, f.File}
+	if id, found := e.funcs[k]; found {
+		return id
+	}
+	id := uint64(len(e.funcs) + 1)
+	e.funcs[k] = id
+
+	m := new(protoBuf)
+	m.uint64(1, id)
+	m.int64(2, e.str(f.Func))
+	m.int64(3, e.str(f.Func))
+	m.int64(4, e.str(f.File))
+	m.int64(5, int64(f.Line))
+	e.buf.message(5, m)
+	return id
+}
+
+func (e *encoder) location(f *Frame) uint64 {
+	if id, found := e.locs[f.PC]; found {
+		return id
+	}
+	id := uint64(len(e.locs) + 1)
+	e.locs[f.PC] = id
+
+	m := new(protoBuf)
+	m.uint64(1, id)
+	m.uint64(2, 1) // the only mapping
+	m.uint64(3, uint64(f.PC))
+	if f.Func != "" {
+		line := new(protoBuf)
+		line.uint64(1, e.function(f))
+		line.int64(2, int64(f.Line))
+		m.message(4, line)
+	}
+	e.buf.message(4, m)
+	return id
+}
+
+func (e *encoder) encode(p *Profile) []byte {
+	e.valueType(1, "samples", "count")
+	e.valueType(1, "cycles", "count")
+
+	mapping := new(protoBuf)
+	mapping.uint64(1, 1)
+	mapping.uint64(3, 1<<32) // memory limit
+	mapping.int64(5, e.str("image"))
+	mapping.uint64(7, 1) // has functions
+	e.buf.message(3, mapping)
+
+	for _, s := range p.Samples {
+		var locs []uint64
+		for _, f := range s.Stack {
+			locs = append(locs, e.location(f))
+		}
+		m := new(protoBuf)
+		m.packedUint64s(1, locs)
+		m.packedInt64s(2, []int64{s.Count, s.Cycles})
+		e.buf.message(2, m)
+	}
+
+	e.valueType(11, "cycles", "count")
+	e.buf.int64(12, p.Period)
+
+	for _, s := range e.strList {
+		e.buf.string(6, s)
+	}
+	return e.buf.bs
+}
+
+// Write writes the profile in gzipped pprof format.
+func (p *Profile) Write(w io.Writer) error {
+	bs := newEncoder().encode(p)
+	z := gzip.NewWriter(w)
+	if _, err := z.Write(bs); err != nil {
+		return err
+	}
+	return z.Close()
+}
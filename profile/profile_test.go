@@ -0,0 +1,42 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	main := &Frame{PC: 0x8004, Func: "main.main", File: "main.g", Line: 3}
+	p := &Profile{
+		Period: 100,
+		Samples: []*Sample{
+			{Stack: []*Frame{main}, Count: 2, Cycles: 200},
+			{
+				Stack:  []*Frame{{PC: 0x8100, Func: "main.f"}, main},
+				Count:  1,
+				Cycles: 100,
+			},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := p.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	z, err := gzip.NewReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs, err := ioutil.ReadAll(z)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{"main.main", "main.f", "main.g", "cycles"} {
+		if bytes.Count(bs, []byte(s)) != 1 {
+			t.Errorf("want %q once in the string table", s)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package profile
+
+// protoBuf is a minimal protocol buffer encoder, enough for writing
+// the pprof profile message.
+type protoBuf struct {
+	bs []byte
+}
+
+func (b *protoBuf) varint(v uint64) {
+	for v >= 0x80 {
+		b.bs = append(b.bs, byte(v)|0x80)
+		v >>= 7
+	}
+	b.bs = append(b.bs, byte(v))
+}
+
+func (b *protoBuf) key(field int, wireType int) {
+	b.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func (b *protoBuf) uint64(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	b.key(field, wireVarint)
+	b.varint(v)
+}
+
+func (b *protoBuf) int64(field int, v int64) {
+	b.uint64(field, uint64(v))
+}
+
+func (b *protoBuf) bytes(field int, bs []byte) {
+	b.key(field, wireBytes)
+	b.varint(uint64(len(bs)))
+	b.bs = append(b.bs, bs...)
+}
+
+func (b *protoBuf) string(field int, s string) {
+	b.bytes(field, []byte(s))
+}
+
+func (b *protoBuf) message(field int, m *protoBuf) {
+	b.bytes(field, m.bs)
+}
+
+func (b *protoBuf) packedUint64s(field int, vs []uint64) {
+	if len(vs) == 0 {
+		return
+	}
+	packed := new(protoBuf)
+	for _, v := range vs {
+		packed.varint(v)
+	}
+	b.message(field, packed)
+}
+
+func (b *protoBuf) packedInt64s(field int, vs []int64) {
+	if len(vs) == 0 {
+		return
+	}
+	packed := new(protoBuf)
+	for _, v := range vs {
+		packed.varint(uint64(v))
+	}
+	b.message(field, packed)
+}
@@ -0,0 +1,75 @@
+package trace
+
+import (
+	"shanhu.io/smlvm/arch"
+)
+
+// Filter selects the ticks to trace. A tick is traced only when it
+// matches all the conditions that are set.
+type Filter struct {
+	// PCStart and PCEnd limit the PC to [PCStart, PCEnd).
+	// PCEnd is ignored when it is 0.
+	PCStart uint32
+	PCEnd   uint32
+
+	// Funcs limits the tick to be in one of the functions, by the full
+	// names in the debug table, like "main.main".
+	Funcs []string
+
+	// Cores limits the tick to be on one of the cores.
+	Cores []int
+
+	// Ring limits the ring before or after the tick, so that the
+	// transitions from and to the ring are also traced. It is ignored
+	// when it is negative.
+	Ring int
+}
+
+// NewFilter creates a filter that matches all ticks.
+func NewFilter() *Filter {
+	return &Filter{Ring: -1}
+}
+
+func (f *Filter) match(e *arch.TraceEntry, funcName func(uint32) string) bool {
+	if e.PC < f.PCStart {
+		return false
+	}
+	if f.PCEnd != 0 && e.PC >= f.PCEnd {
+		return false
+	}
+	if f.Ring >= 0 {
+		r := byte(f.Ring)
+		if e.Ring != r && e.RingAfter != r {
+			return false
+		}
+	}
+
+	if len(f.Cores) > 0 {
+		found := false
+		for _, c := range f.Cores {
+			if c == e.Core {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.Funcs) > 0 {
+		name := funcName(e.PC)
+		found := false
+		for _, fn := range f.Funcs {
+			if fn == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,98 @@
+// Package trace writes human readable execution traces of arch8
+// machines.
+package trace
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"shanhu.io/smlvm/arch"
+	"shanhu.io/smlvm/dasm"
+	"shanhu.io/smlvm/debug"
+)
+
+var regNames = []string{"r0", "r1", "r2", "r3", "r4", "sp", "ret", "pc"}
+
+// Writer is an arch.Tracer that writes a line for each traced tick.
+// Each line has the core, the cycle, the ring, the PC, the symbol of
+// the PC, the disassembled instruction, and the registers changed.
+type Writer struct {
+	w      io.Writer
+	funcs  *debug.FuncIndex
+	filter *Filter
+	err    error
+}
+
+// NewWriter creates a trace writer. t is the debug table for symbols,
+// and f filters the ticks to trace. Both can be nil.
+func NewWriter(w io.Writer, t *debug.Table, f *Filter) *Writer {
+	ret := &Writer{w: w, filter: f}
+	if t != nil {
+		ret.funcs = debug.NewFuncIndex(t)
+	}
+	return ret
+}
+
+// Err returns the first error met when writing the trace.
+func (w *Writer) Err() error { return w.err }
+
+func (w *Writer) funcName(pc uint32) string {
+	if w.funcs == nil {
+		return ""
+	}
+	name, _ := w.funcs.Find(pc)
+	return name
+}
+
+func (w *Writer) symbol(pc uint32) string {
+	if w.funcs == nil {
+		return ""
+	}
+	if _, f := w.funcs.Find(pc); f == nil {
+		return ""
+	}
+	return w.funcs.Symbol(pc)
+}
+
+// Trace writes the trace line of a tick.
+func (w *Writer) Trace(e *arch.TraceEntry) {
+	if w.err != nil {
+		return
+	}
+	if w.filter != nil && !w.filter.match(e, w.funcName) {
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "c%d #%d r%d %08x", e.Core, e.Cycle, e.Ring, e.PC)
+	if sym := w.symbol(e.PC); sym != "" {
+		fmt.Fprintf(buf, " %-24s", sym)
+	}
+
+	if e.Interrupt {
+		fmt.Fprintf(buf, "  interrupt %d", e.IntCode)
+	} else {
+		fmt.Fprintf(buf, "  %-24s", dasm.LineStr(e.Inst))
+	}
+	if e.RingAfter != e.Ring {
+		fmt.Fprintf(buf, " ring=%d->%d", e.Ring, e.RingAfter)
+	}
+	for i, name := range regNames {
+		if e.Regs[i] == e.RegsAfter[i] {
+			continue
+		}
+		if i == arch.PC && e.RegsAfter[i] == e.Regs[i]+4 {
+			continue // normal pc increment
+		}
+		fmt.Fprintf(buf, " %s=%08x", name, e.RegsAfter[i])
+	}
+	if e.Excep != nil {
+		fmt.Fprintf(buf, " excep=%q", e.Excep.Error())
+	}
+	line := strings.TrimRight(buf.String(), " ") + "\n"
+	if _, err := io.WriteString(w.w, line); err != nil {
+		w.err = err
+	}
+}
@@ -0,0 +1,64 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"shanhu.io/smlvm/arch"
+	asminst "shanhu.io/smlvm/asm/inst"
+	"shanhu.io/smlvm/debug"
+)
+
+func TestWriter(t *testing.T) {
+	insts := []uint32{
+		asminst.Imm(arch.ADDI, arch.R1, arch.R0, 5), // 8000
+		asminst.Imm(arch.ADDI, arch.R2, arch.R1, 1), // 8004
+		asminst.Sys(arch.HALT, 0, 0),                // 8008
+	}
+	prog := new(bytes.Buffer)
+	for _, in := range insts {
+		binary.Write(prog, binary.LittleEndian, in)
+	}
+
+	tab := debug.NewTable()
+	tab.Funcs["main.main"] = &debug.Func{Start: arch.InitPC, Size: 4}
+	tab.Funcs["main.f"] = &debug.Func{Start: arch.InitPC + 4, Size: 8}
+
+	for _, test := range []struct {
+		funcs []string
+		want  []string
+	}{
+		{nil, []string{
+			"c0 #1 r0 00008000 main.main+0",
+			"addi r1 r0 5",
+			"r1=00000005",
+		}},
+		{[]string{"main.f"}, []string{"main.f+0", "r2=00000006"}},
+	} {
+		m := arch.NewMachine(&arch.Config{InitPC: arch.InitPC})
+		err := m.WriteBytes(bytes.NewReader(prog.Bytes()), arch.InitPC)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := new(bytes.Buffer)
+		f := NewFilter()
+		f.Funcs = test.funcs
+		m.SetTracer(NewWriter(out, tab, f))
+		if _, e := m.Run(10); !arch.IsHalt(e) {
+			t.Fatalf("want halt, got %v", e)
+		}
+
+		got := out.String()
+		for _, s := range test.want {
+			if !strings.Contains(got, s) {
+				t.Errorf("trace %q missing %q", got, s)
+			}
+		}
+		if test.funcs != nil && strings.Contains(got, "main.main") {
+			t.Errorf("trace %q is not filtered", got)
+		}
+	}
+}